@@ -1,3 +1,8 @@
+// Package argv splits command-line inputs into positional args and flags.
+//
+// Deprecated: basicli no longer uses this package. The command line is parsed
+// against the command tree instead, which knows the flags each command accepts
+// and which of them take a value.
 package argv
 
 // parse_args.go handles parsing of command-line inputs to positional args or
@@ -5,6 +10,9 @@ package argv
 
 import "strings"
 
+// Parse splits `inputs` into positional args and flag values by flag name.
+//
+// Deprecated: basicli no longer uses this package (see the package docs).
 func Parse(inputs []string) ([]string, map[string][]string) {
   if len(inputs) == 0 {
     return nil, nil
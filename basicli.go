@@ -2,3 +2,12 @@ package basicli
 
 const structTag = "basicli"
 const methodExec = "Exec"
+const methodBefore = "Before"
+const methodAfter = "After"
+const methodMiddleware = "Middleware"
+const methodRules = "Rules"
+const methodValidate = "Validate"
+
+// envDebug names the environment variable which, when set to any non-empty
+// value, enables diagnostic output such as the stack of a recovered panic.
+const envDebug = "BASICLI_DEBUG"
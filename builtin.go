@@ -0,0 +1,27 @@
+package basicli
+
+import "reflect"
+
+// builtins maps the names of the built-in commands to their implementations.
+//
+// A built-in command is only served when the root command doesn't declare a
+// subcommand of the same name.
+var builtins = map[string]func(cfg *config, root *command, rv reflect.Value, args []string) error{
+  cmdCompletion: runCompletionBuiltin,
+  cmdComplete:   runCompleteBuiltin,
+}
+
+// builtin looks up the built-in command addressed by the raw command-line
+// `args`, if any, returning a function which runs it.
+func builtin(cfg *config, root *command, args []string) (func(rv reflect.Value) error, bool) {
+  if len(args) == 0 {
+    return nil, false
+  }
+  fn, ok := builtins[args[0]]
+  if !ok || root.lookup(args[0]) != nil {
+    return nil, false
+  }
+  return func(rv reflect.Value) error {
+    return fn(cfg, root, rv, args[1:])
+  }, true
+}
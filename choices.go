@@ -0,0 +1,49 @@
+package basicli
+
+import (
+  "reflect"
+  "slices"
+
+  "github.com/illbjorn/basicli/tag"
+)
+
+// Enum is implemented by the types of flags (or positional args) which only
+// accept a fixed set of values, as an alternative to the `choices` directive:
+//
+//  type Format string
+//
+//  func (Format) Choices() []string { return []string{"json", "yaml", "text"} }
+//
+// Slices of an `Enum` type accept the same values for each element.
+type Enum interface {
+  Choices() []string
+}
+
+// choices produces the values accepted by a field of type `rt` with tag `t`:
+// those of the `choices` directive, else those of its `Enum` type, else nil for
+// any value.
+func choices(t tag.Tag, rt reflect.Type) []string {
+  if len(t.Choices) > 0 {
+    return t.Choices
+  }
+  if rt.Kind() == reflect.Slice {
+    rt = rt.Elem()
+  }
+  if enum, ok := reflect.New(rt).Interface().(Enum); ok {
+    return enum.Choices()
+  }
+  return nil
+}
+
+// choose checks each of `vs` is one of `choices`, unless any value is accepted.
+func choose(choices, vs []string) *ChoiceError {
+  if len(choices) == 0 {
+    return nil
+  }
+  for _, v := range vs {
+    if !slices.Contains(choices, v) {
+      return &ChoiceError{Value: v, Choices: choices, Suggestions: suggest(v, choices, false)}
+    }
+  }
+  return nil
+}
@@ -0,0 +1,77 @@
+package basicli
+
+import (
+  "errors"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockFormat string
+
+func (MockFormat) Choices() []string { return []string{"json", "yaml", "text"} }
+
+type MockChoices struct {
+  Output  MockFormat   `basicli:"output,o,default=text"`
+  Env     string       `basicli:"env,choices=dev|staging|prod,env=BASICLI_TEST_ENV"`
+  Formats []MockFormat `basicli:"format"`
+  Target  string       `basicli:"target,pos=0,choices='web|api'"`
+}
+
+func (MockChoices) Exec() error { return nil }
+
+type MockChoicesBadDefault struct {
+  Output MockFormat `basicli:"output,default=xml"`
+}
+
+func TestChoices(t *testing.T) {
+  // (good) Values from the choices directive, or an Enum type
+  mc, err := runArgs[MockChoices]("web", "--output", "json", "--env", "prod", "--format", "yaml", "--format", "text")
+  assert.NilError(t, err)
+  assert.Equal(t, mc.Output, MockFormat("json"))
+  assert.Equal(t, mc.Env, "prod")
+  assert.DeepEqual(t, mc.Formats, []MockFormat{"yaml", "text"})
+  assert.Equal(t, mc.Target, "web")
+
+  // (bad) Values outside of the set, with suggestions
+  _, err = runArgs[MockChoices]("web", "--output", "jsn")
+  assert.Error(t, err, "invalid value ['jsn'] for flag [output]: expected one of 'json', 'yaml' or 'text', did you mean 'json'?")
+  var choice *ChoiceError
+  assert.Assert(t, errors.As(err, &choice))
+  assert.Equal(t, choice.Value, "jsn")
+  _, err = runArgs[MockChoices]("web", "--format", "json", "--format", "xml")
+  assert.Error(t, err, "invalid value ['xml'] for flag [format]: expected one of 'json', 'yaml' or 'text'")
+  _, err = runArgs[MockChoices]("db")
+  assert.Error(t, err, "invalid value ['db'] for arg [target]: expected one of 'web' or 'api'")
+  t.Setenv("BASICLI_TEST_ENV", "qa")
+  _, err = runArgs[MockChoices]("web")
+  assert.Error(t, err, "failed to set flag [env] from environment variable [BASICLI_TEST_ENV]: expected one of 'dev', 'staging' or 'prod'")
+
+  // (bad) Defaults outside of the set
+  assert.ErrorContains(t, Validate(&MockChoicesBadDefault{}),
+    "['app'] flag ['--output'] has default value ['xml']: expected one of 'json', 'yaml' or 'text'")
+}
+
+func TestChoicesHelp(t *testing.T) {
+  var mc MockChoices
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&mc), "app")
+  assert.NilError(t, err)
+  rv := Concrete(reflect.ValueOf(&mc))
+  complete := func(words ...string) []string {
+    return complete(newConfig(nil), root, rv, words)
+  }
+
+  // Choices are completed, for flags and positional args
+  assert.DeepEqual(t, complete("--output", ""), []string{"json", "yaml", "text"})
+  assert.DeepEqual(t, complete("--env", "st"), []string{"staging"})
+  assert.DeepEqual(t, complete("a"), []string{"api"})
+  assert.DeepEqual(t, root.flag("output").Choices, []string{"json", "yaml", "text"})
+
+  // And documented
+  var b strings.Builder
+  writeDoc(&b, root)
+  assert.Check(t, strings.Contains(b.String(), "| `--env` | `string` |  | `BASICLI_TEST_ENV` | no | One of `dev`, `staging`, `prod`. |"), b.String())
+  assert.Check(t, strings.Contains(b.String(), "| `<target>` | `string` |  | yes | One of `web`, `api`. |"), b.String())
+}
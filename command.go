@@ -0,0 +1,696 @@
+package basicli
+
+import (
+  "fmt"
+  "reflect"
+  "slices"
+  "strings"
+
+  "github.com/illbjorn/basicli/tag"
+)
+
+// command describes a single node of the command tree derived from a struct
+// type.
+//
+// Nested struct fields become subcommands, exported methods returning an
+// `error` (other than those basicli calls itself, such as `Exec`) become leaf
+// subcommands and all remaining exported fields become flags. Fields tagged `-`
+// are ignored.
+type command struct {
+  Name      string
+  Aliases   []string
+  FieldName string // Go field (or method) name, which always matches as well
+  Help      string
+  Path      []string // Names from the root command down to this one
+  Parent    *command // nil for the root
+  Type      reflect.Type
+  Index     []int  // Field index on the parent struct, nil for the root
+  Method    string // Method name, for method leaf subcommands
+  Flags     []*flagDef
+  Args      []*argDef // Positional args, ordered by position
+  Parents   []reflect.StructField // Fields tagged `-,parent`
+  Rules     []Rule
+  Commands  []*command
+}
+
+// flagDef describes a single flag, derived from a struct field and its tag.
+type flagDef struct {
+  Name       string
+  Aliases    []string
+  Field      reflect.StructField
+  Default    string
+  HasDefault bool
+  Required   bool
+  Env        string
+  Help       string
+  Choices    []string // Values accepted, or nil for any
+  Limits     []limit
+  Persistent bool   // Also accepted by every subcommand
+  Group      string // Embedded struct or option group declaring the flag, if any
+  Selector   string // Go selector of the field within the command's struct
+}
+
+// argDef describes a single positional arg, derived from a struct field tagged
+// with `pos=N` (zero-based) or `pos=rest`.
+type argDef struct {
+  Name       string
+  Field      reflect.StructField
+  Pos        int
+  Rest       bool // Collects all remaining args into a slice
+  Default    string
+  HasDefault bool
+  Required   bool
+  Help       string
+  Choices    []string // Values accepted, or nil for any
+  Limits     []limit
+}
+
+// newCommand builds the command tree rooted at struct type `rt`, naming what
+// tags don't with the naming strategy of `cfg`, failing on the first malformed
+// tag.
+func newCommand(cfg *config, rt reflect.Type, name string) (*command, error) {
+  return buildCommand(cfg, indirect(rt), name, nil, []string{name}, nil, nil)
+}
+
+func buildCommand(cfg *config, rt reflect.Type, name string, aliases, path []string, index []int, parent *command) (*command, error) {
+  cmd := &command{
+    Name:    name,
+    Aliases: aliases,
+    Path:    path,
+    Parent:  parent,
+    Type:    rt,
+    Index:   index,
+    Rules:   rules(rt),
+  }
+  if err := cmd.addFields(cfg, rt, nil, "", ""); err != nil {
+    return nil, err
+  }
+
+  // The rest arg always comes last
+  slices.SortStableFunc(cmd.Args, func(a, b *argDef) int {
+    switch {
+    case a.Rest == b.Rest:
+      return a.Pos - b.Pos
+    case a.Rest:
+      return 1
+    default:
+      return -1
+    }
+  })
+
+  // Exported methods (with either receiver) shaped like commands are leaf
+  // subcommands, which share the flags and positional args of the struct
+  // declaring them
+  prt := reflect.PointerTo(rt)
+  for i := range prt.NumMethod() {
+    method := prt.Method(i)
+    if reserved(rt, method.Name) || promoted(rt, method.Name) || !commandShaped(method.Type) {
+      continue
+    }
+    name := cfg.naming(method.Name)
+    cmd.Commands = append(cmd.Commands, &command{
+      Name:      name,
+      FieldName: method.Name,
+      Path:      extend(path, name),
+      Parent:    cmd,
+      Type:      rt,
+      Method:    method.Name,
+      Flags:     cmd.Flags,
+      Args:      cmd.Args,
+      Rules:     cmd.Rules,
+    })
+  }
+
+  return cmd, nil
+}
+
+// addFields adds the fields of struct type `rt` to `cmd`: nested structs as
+// subcommands and everything else as flags (or positional args). `rt` is found
+// at `index` within the struct of `cmd`, as (part of) `group` unless it's that
+// struct itself, with its flags named beginning with `prefix`.
+//
+// The fields of anonymous embedded structs are added as if declared by the
+// embedding struct, so shared options can be declared once:
+//
+//  type OutputOpts struct {
+//    Format  string
+//    NoColor bool
+//  }
+//
+//  type List struct {
+//    OutputOpts
+//    All bool
+//  }
+//
+// Unexported embedded pointers are skipped, as they can't be allocated.
+//
+// Struct fields tagged `prefix=<name>` are option groups rather than
+// subcommands, whose fields are flags named `--<name>.<flag>`, as are those of
+// structs nested within them. Unless they declare their own, flags of option
+// groups are bound to an environment variable derived from their name, such as
+// `DB_HOST` for `--db.host`:
+//
+//  type Serve struct {
+//    DB struct {
+//      Host string
+//      Port int
+//    } `basicli:"prefix=db"`
+//  }
+func (self *command) addFields(cfg *config, rt reflect.Type, index []int, group, prefix string) error {
+  for i := range rt.NumField() {
+    sf := rt.Field(i)
+    sf.Index = append(index[:len(index):len(index)], i)
+    embedded := sf.Anonymous && indirect(sf.Type).Kind() == reflect.Struct
+    if !sf.IsExported() && (!embedded || sf.Type.Kind() == reflect.Pointer) {
+      continue
+    }
+
+    t, err := tag.ParseStrict(sf.Tag.Get(structTag))
+    if err != nil {
+      return tagError(self.Path, sf, err)
+    }
+    if t.Flags.Parent() {
+      self.Parents = append(self.Parents, sf)
+    }
+    if t.Flags.Skip() {
+      continue
+    }
+    // Structs nested in option groups are option groups themselves
+    if len(prefix) > 0 && !embedded && len(t.Prefix) == 0 && indirect(sf.Type).Kind() == reflect.Struct {
+      t.Prefix = t.Name
+      if len(t.Prefix) == 0 {
+        t.Prefix = cfg.naming(sf.Name)
+      }
+    }
+    if embedded || len(t.Prefix) > 0 {
+      title, prefix := t.Prefix, dotted(prefix, t.Prefix)
+      if len(title) == 0 {
+        title = t.Name
+      }
+      if len(title) == 0 {
+        title = indirect(sf.Type).Name()
+      }
+      if err := self.addFields(cfg, indirect(sf.Type), sf.Index, dotted(group, title), prefix); err != nil {
+        return err
+      }
+      continue
+    }
+    if indirect(sf.Type).Kind() == reflect.Struct {
+      // Recursive pointer types would otherwise describe an infinite tree
+      if self.recursive(indirect(sf.Type)) {
+        continue
+      }
+      name := t.Name
+      if len(name) == 0 {
+        name = cfg.naming(sf.Name)
+      }
+      child, err := buildCommand(cfg, indirect(sf.Type), name, t.Aliases, extend(self.Path, name), sf.Index, self)
+      if err != nil {
+        return err
+      }
+      child.FieldName = sf.Name
+      child.Help = t.Help
+      self.Commands = append(self.Commands, child)
+      continue
+    }
+
+    if t.Flags.Positional() {
+      name := t.Name
+      if len(name) == 0 {
+        name = cfg.naming(sf.Name)
+      }
+      self.Args = append(self.Args, &argDef{
+        Name:       name,
+        Field:      sf,
+        Pos:        t.Pos,
+        Rest:       t.Flags.Rest(),
+        Default:    t.Default,
+        HasDefault: t.Flags.HasDefault(),
+        Required:   t.Flags.Required(),
+        Help:       t.Help,
+        Choices:    choices(t, sf.Type),
+        Limits:     limits(t),
+      })
+      continue
+    }
+
+    name := t.Name
+    if len(name) == 0 {
+      name = cfg.naming(sf.Name)
+    }
+    aliases, env := t.Aliases, t.Env
+    if len(prefix) > 0 {
+      name = dotted(prefix, name)
+      aliases = make([]string, len(t.Aliases))
+      for i, alias := range t.Aliases {
+        aliases[i] = dotted(prefix, alias)
+      }
+      if len(env) == 0 {
+        env = strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(name))
+      }
+    }
+    self.Flags = append(self.Flags, &flagDef{
+      Name:       name,
+      Aliases:    aliases,
+      Field:      sf,
+      Default:    t.Default,
+      HasDefault: t.Flags.HasDefault(),
+      Required:   t.Flags.Required(),
+      Env:        env,
+      Help:       t.Help,
+      Choices:    choices(t, sf.Type),
+      Limits:     limits(t),
+      Persistent: t.Flags.Persistent(),
+      Group:      group,
+      Selector:   selector(self.Type, sf.Index),
+    })
+  }
+  return nil
+}
+
+// recursive reports whether struct type `rt` is that of `cmd` or one of its
+// ancestors.
+func (self *command) recursive(rt reflect.Type) bool {
+  for c := self; c != nil; c = c.Parent {
+    if c.Type == rt {
+      return true
+    }
+  }
+  return false
+}
+
+// takesArgs reports whether `cmd` accepts positional args: those bound to its
+// positional arg fields, or any at all when the method it runs accepts them as
+// a `[]string`.
+func (self *command) takesArgs() bool {
+  return len(self.Args) > 0 || self.accepts(typeArgs)
+}
+
+// accepts reports whether the method `cmd` runs (its method leaf, or `Exec`)
+// has a parameter of type `rt`.
+func (self *command) accepts(rt reflect.Type) bool {
+  name := self.Method
+  if len(name) == 0 {
+    name = methodExec
+  }
+  method := methodType(self.Type, name)
+  if method == nil {
+    return false
+  }
+  for i := range method.NumIn() {
+    if method.In(i) == rt {
+      return true
+    }
+  }
+  return false
+}
+
+// arity reports the minimum and maximum number of positional args `cmd`
+// accepts, with a maximum of -1 when a rest arg accepts any number.
+//
+// Fixed positional args are required unless they have a default value, and a
+// rest arg requires at least one value when marked required.
+func (self *command) arity() (lo, hi int) {
+  for _, arg := range self.Args {
+    switch {
+    case arg.Rest:
+      hi = -1
+      if arg.Required {
+        lo = max(lo, self.fixedArgs()+1)
+      }
+    default:
+      if hi >= 0 {
+        hi = max(hi, arg.Pos+1)
+      }
+      if !arg.HasDefault {
+        lo = max(lo, arg.Pos+1)
+      }
+    }
+  }
+  return lo, hi
+}
+
+// argRequired reports whether positional arg `arg` of `cmd` must be provided.
+func (self *command) argRequired(arg *argDef) bool {
+  if arg.Rest {
+    return arg.Required
+  }
+  lo, _ := self.arity()
+  return arg.Pos < lo
+}
+
+// fixedArgs counts the positions occupied by fixed (non-rest) positional args.
+func (self *command) fixedArgs() int {
+  var n int
+  for _, arg := range self.Args {
+    if !arg.Rest {
+      n = max(n, arg.Pos+1)
+    }
+  }
+  return n
+}
+
+// walk calls `fn` for `cmd` and every command beneath it, depth-first in
+// declaration order.
+func (self *command) walk(fn func(*command)) {
+  fn(self)
+  for _, child := range self.Commands {
+    child.walk(fn)
+  }
+}
+
+// matches reports whether `name` refers to this command by its name, one of
+// its aliases or its Go field name, case-insensitively.
+func (self *command) matches(name string) bool {
+  if strings.EqualFold(self.Name, name) || strings.EqualFold(self.FieldName, name) {
+    return true
+  }
+  return slices.ContainsFunc(self.Aliases, containsStrFold(name))
+}
+
+// lookup locates the immediate subcommand referred to by `name`.
+func (self *command) lookup(name string) *command {
+  for _, child := range self.Commands {
+    if child.matches(name) {
+      return child
+    }
+  }
+  return nil
+}
+
+// find locates the immediate subcommand referred to by `name`, returning nil
+// when there is none. With prefix matching enabled, `name` may also be an
+// unambiguous prefix of a subcommand's name or aliases.
+func (self *command) find(cfg *config, name string) (*command, error) {
+  if child := self.lookup(name); child != nil || !cfg.prefixMatching {
+    return child, nil
+  }
+  matched, names := prefixMatch(name, self.Commands, true, func(child *command) []string {
+    return append([]string{child.Name}, child.Aliases...)
+  })
+  switch len(matched) {
+  case 0:
+    return nil, nil
+  case 1:
+    return matched[0], nil
+  default:
+    return nil, usageError(&AmbiguousError{Kind: "command", Name: name, Candidates: names})
+  }
+}
+
+// scope produces the command whose struct declares the flags of `cmd`: its
+// parent for method leaf subcommands, otherwise `cmd` itself.
+func (self *command) scope() *command {
+  if len(self.Method) > 0 {
+    return self.Parent
+  }
+  return self
+}
+
+// inherited collects the persistent flags of the ancestors of `cmd`, nearest
+// first.
+func (self *command) inherited() []*flagDef {
+  var flags []*flagDef
+  for c := self.scope().Parent; c != nil; c = c.Parent {
+    for _, flag := range c.Flags {
+      if flag.Persistent {
+        flags = append(flags, flag)
+      }
+    }
+  }
+  return flags
+}
+
+// visibleFlags produces every flag `cmd` accepts: its own, followed by those it
+// inherits.
+func (self *command) visibleFlags() []*flagDef {
+  return append(self.Flags[:len(self.Flags):len(self.Flags)], self.inherited()...)
+}
+
+// conflict reports a flag accepted by `cmd` under the same name as a persistent
+// flag it inherits from a different command.
+func (self *command) conflict() error {
+  owners := make(map[string]*command)
+  for c := self.scope(); c != nil; c = c.Parent {
+    for _, flag := range c.Flags {
+      if c != self.scope() && !flag.Persistent {
+        continue
+      }
+      for _, name := range flag.names() {
+        if owner, ok := owners[name]; ok && owner != c {
+          return fmt.Errorf(
+            "flag ['%[1]s'] of ['%[2]s'] conflicts with persistent flag ['%[1]s'] of ['%[3]s']",
+            flagDisplay(name), strings.Join(owner.Path, " "), strings.Join(c.Path, " "),
+          )
+        }
+        owners[name] = c
+      }
+    }
+  }
+  return nil
+}
+
+// flag locates the flag referred to by `name` (without dashes) among those
+// `cmd` accepts, including inherited persistent flags. Besides their names and
+// aliases, flags are matched by their Go selector, such as `DB.Host` for a
+// field of an option group. Unlike subcommands, flags are matched
+// case-sensitively (see `Naming`).
+func (self *command) flag(name string) *flagDef {
+  for _, flag := range self.visibleFlags() {
+    if flag.Name == name || flag.Selector == name || slices.Contains(flag.Aliases, name) {
+      return flag
+    }
+  }
+  return nil
+}
+
+// flagFold locates the flag referred to by `name` like `flag`, but
+// case-insensitively.
+func (self *command) flagFold(name string) *flagDef {
+  for _, flag := range self.visibleFlags() {
+    if strings.EqualFold(flag.Name, name) || strings.EqualFold(flag.Selector, name) || slices.ContainsFunc(flag.Aliases, containsStrFold(name)) {
+      return flag
+    }
+  }
+  return nil
+}
+
+// findFlag locates the flag referred to by `name` (without dashes), returning
+// nil when there is none. With case-insensitive flags enabled, exact matches
+// take precedence over those differing in case. With prefix matching enabled,
+// `name` may also be an unambiguous prefix of a flag's name or aliases.
+func (self *command) findFlag(cfg *config, name string) (*flagDef, error) {
+  flag := self.flag(name)
+  if flag == nil && cfg.foldFlags {
+    flag = self.flagFold(name)
+  }
+  if flag != nil || !cfg.prefixMatching {
+    return flag, nil
+  }
+  matched, names := prefixMatch(name, self.visibleFlags(), cfg.foldFlags, (*flagDef).names)
+  switch len(matched) {
+  case 0:
+    return nil, nil
+  case 1:
+    return matched[0], nil
+  default:
+    return nil, usageError(&AmbiguousError{Kind: "flag", Name: name, Candidates: names})
+  }
+}
+
+// prefixMatch collects the distinct `items` which have a name (as produced by
+// `names`) beginning with `prefix`, along with every name which matched.
+func prefixMatch[E comparable](prefix string, items []E, fold bool, names func(E) []string) ([]E, []string) {
+  var matched []E
+  var matchedNames []string
+  for _, item := range items {
+    for _, name := range names(item) {
+      ok := strings.HasPrefix(name, prefix)
+      if fold {
+        ok = len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)
+      }
+      if !ok {
+        continue
+      }
+      if !slices.Contains(matched, item) {
+        matched = append(matched, item)
+      }
+      matchedNames = append(matchedNames, name)
+    }
+  }
+  return matched, matchedNames
+}
+
+// frame is a single command along a resolved command path, along with the
+// struct value holding it.
+type frame struct {
+  cmd *command
+  rv  reflect.Value
+}
+
+// descend extends `path` with the subcommand named by `name` of the command at
+// its end, reporting whether there was one. Method leaf subcommands have no
+// subcommands of their own.
+//
+// The struct descended into is allocated if it's a nil pointer, and has its
+// parent fields populated (see `injectParents`). An error is returned for an
+// ambiguous subcommand prefix, or a subcommand whose flags conflict with those
+// it inherits.
+func descend(cfg *config, path []frame, name string) ([]frame, bool, error) {
+  cur := leaf(path)
+  if len(cur.cmd.Method) > 0 {
+    return path, false, nil
+  }
+  child, err := cur.cmd.find(cfg, name)
+  if err != nil || child == nil {
+    return path, false, err
+  }
+  rv := cur.rv
+  if len(child.Method) == 0 {
+    rv = allocate(fieldByIndex(rv, child.Index))
+    injectParents(child, rv, path)
+  }
+  return append(path, frame{child, rv}), true, child.conflict()
+}
+
+// owner locates the frame of `path` holding the struct which declares `flag`,
+// returning its index.
+func owner(path []frame, flag *flagDef) int {
+  path = structs(path)
+  for i := len(path) - 1; i > 0; i-- {
+    if slices.Contains(path[i].cmd.Flags, flag) {
+      return i
+    }
+  }
+  return 0
+}
+
+// leaf produces the final frame of a resolved command `path`.
+func leaf(path []frame) frame {
+  return path[len(path)-1]
+}
+
+// structs produces the frames of `path` which hold distinct structs, omitting a
+// trailing method leaf subcommand (whose struct is that of its parent).
+func structs(path []frame) []frame {
+  if len(leaf(path).cmd.Method) > 0 {
+    return path[:len(path)-1]
+  }
+  return path
+}
+
+// reserved reports whether the method `name` on struct type `rt` is one which
+// basicli calls itself, rather than a leaf subcommand.
+func reserved(rt reflect.Type, name string) bool {
+  switch name {
+  case methodExec, methodBefore, methodAfter, methodMiddleware, methodRules, methodValidate:
+    return true
+  }
+  if field, ok := strings.CutPrefix(name, methodCompletePrefix); ok {
+    if _, ok := rt.FieldByName(field); ok {
+      return true
+    }
+  }
+  return false
+}
+
+// promoted reports whether method `name` of struct type `rt` is promoted from
+// one of its embedded fields, such as the `Lock` method of an embedded
+// `sync.Mutex`. Those belong to the embedded type rather than the command.
+func promoted(rt reflect.Type, name string) bool {
+  for i := range rt.NumField() {
+    sf := rt.Field(i)
+    if !sf.Anonymous {
+      continue
+    }
+    et := sf.Type
+    if et.Kind() != reflect.Interface {
+      et = reflect.PointerTo(indirect(et))
+    }
+    if _, ok := et.MethodByName(name); ok {
+      return true
+    }
+  }
+  return false
+}
+
+// commandShaped reports whether method type `mt` (including its receiver)
+// returns exactly an `error`, as commands do. Methods returning anything else,
+// such as `String() string`, or nothing at all, aren't commands.
+func commandShaped(mt reflect.Type) bool {
+  return mt.NumOut() == 1 && mt.Out(0) == typeError
+}
+
+// indirect strips any pointer indirection from `rt`.
+func indirect(rt reflect.Type) reflect.Type {
+  for rt.Kind() == reflect.Pointer {
+    rt = rt.Elem()
+  }
+  return rt
+}
+
+// dotted joins `prefix` and `name` with a '.', unless either is empty.
+func dotted(prefix, name string) string {
+  if len(prefix) == 0 || len(name) == 0 {
+    return prefix + name
+  }
+  return prefix + "." + name
+}
+
+// goName produces the Go field name of `flag`, qualified by the embedded struct
+// declaring it, if any.
+func (self *flagDef) goName() string {
+  if len(self.Group) > 0 {
+    return self.Group + "." + self.Field.Name
+  }
+  return self.Field.Name
+}
+
+// selector produces the Go selector of the field at `index` within struct type
+// `rt`, leaving out embedded structs as their fields are promoted.
+func selector(rt reflect.Type, index []int) string {
+  var names []string
+  for _, i := range index {
+    sf := indirect(rt).Field(i)
+    if !sf.Anonymous {
+      names = append(names, sf.Name)
+    }
+    rt = sf.Type
+  }
+  return strings.Join(names, ".")
+}
+
+// matchNames produces the names `flag` is matched by: its name, its aliases and
+// its Go selector.
+func (self *flagDef) matchNames() []string {
+  names := self.names()
+  if !slices.Contains(names, self.Selector) {
+    names = append(names, self.Selector)
+  }
+  return names
+}
+
+// takesValue reports whether `flag` consumes the following word as its value,
+// as every flag but a bool does.
+func (self *flagDef) takesValue() bool {
+  return indirect(self.Field.Type).Kind() != reflect.Bool
+}
+
+// names produces the name of `flag` followed by its aliases.
+func (self *flagDef) names() []string {
+  return append([]string{self.Name}, self.Aliases...)
+}
+
+// extend returns a copy of `path` with `name` appended, never sharing a backing
+// array with `path`.
+func extend(path []string, name string) []string {
+  return append(path[:len(path):len(path)], name)
+}
+
+// flagDisplay renders a flag name as it would be typed on the command line.
+func flagDisplay(name string) string {
+  if len(name) == 1 {
+    return "-" + name
+  }
+  return "--" + name
+}
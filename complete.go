@@ -0,0 +1,100 @@
+package basicli
+
+import (
+  "fmt"
+  "reflect"
+  "slices"
+  "strings"
+)
+
+// cmdComplete is the name of the hidden built-in command which the completion
+// scripts call back into to complete a partial command line at runtime.
+//
+// Its args are the words of the command line following the executable, up to
+// and including the (possibly empty) word being completed. Candidates are
+// printed one per line.
+const cmdComplete = "__complete"
+
+// methodCompletePrefix prefixes the name of the optional method, declared on a
+// command struct, which completes the values of the flag (or positional arg)
+// backed by the field of the same name:
+//
+//  func (Deploy) CompleteCluster(prefix string) []string
+const methodCompletePrefix = "Complete"
+
+// runCompleteBuiltin prints the completion candidates for the final word of
+// `args`, one per line.
+func runCompleteBuiltin(cfg *config, root *command, rv reflect.Value, args []string) error {
+  for _, candidate := range complete(cfg, root, rv, args) {
+    fmt.Fprintln(cfg.io.Out, candidate)
+  }
+  return nil
+}
+
+// complete produces the completion candidates for the final word of `words`.
+//
+// The preceding words are resolved against the command tree by `parseLine`, as
+// `Unmarshal` resolves the command line, as far as they can be. The final word
+// is then completed as a flag value (when following a value flag), a flag name
+// (when it begins with `-`) or a subcommand name or positional arg value.
+func complete(cfg *config, root *command, rv reflect.Value, words []string) []string {
+  if len(words) == 0 {
+    words = []string{""}
+  }
+  cur := words[len(words)-1]
+
+  l, _ := parseLine(cfg, root, rv, words[:len(words)-1])
+  path, pending, positional := l.path, l.pending, len(l.args)
+  cmd, rv := leaf(path).cmd, leaf(path).rv
+
+  var candidates []string
+  switch {
+  case pending != nil:
+    // Inherited flags are completed by the struct declaring them
+    candidates = completeValue(path[owner(path, pending)].rv, pending.Field.Name, pending.Choices, cur)
+
+  case strings.HasPrefix(cur, "-"):
+    for _, flag := range cmd.visibleFlags() {
+      for _, name := range flag.names() {
+        candidates = append(candidates, flagDisplay(name))
+      }
+    }
+
+  default:
+    if positional == 0 {
+      for _, child := range cmd.Commands {
+        candidates = append(candidates, child.Name)
+        candidates = append(candidates, child.Aliases...)
+      }
+    }
+    for _, arg := range cmd.Args {
+      if arg.Rest && positional >= cmd.fixedArgs() || !arg.Rest && arg.Pos == positional {
+        candidates = append(candidates, completeValue(rv, arg.Field.Name, arg.Choices, cur)...)
+      }
+    }
+  }
+
+  // Only offer candidates which extend what has been typed so far
+  matched := candidates[:0]
+  for _, candidate := range candidates {
+    if strings.HasPrefix(candidate, cur) {
+      matched = append(matched, candidate)
+    }
+  }
+  return matched
+}
+
+// completeValue calls the `Complete<Field>` method for field `field` on struct
+// `rv`, when one is declared with signature `func(prefix string) []string`.
+// Otherwise the field's `choices` are offered, if any.
+func completeValue(rv reflect.Value, field string, choices []string, prefix string) []string {
+  method := methodByName(rv, methodCompletePrefix+field)
+  if !method.IsValid() {
+    return slices.Clone(choices)
+  }
+  fn, ok := method.Interface().(func(string) []string)
+  if !ok {
+    return nil
+  }
+  return fn(prefix)
+}
@@ -0,0 +1,62 @@
+package basicli
+
+import (
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockComplete struct {
+  Verbose bool              `basicli:"verbose,v"`
+  Cloud   MockCompleteCloud `basicli:"cloud,c"`
+}
+
+type MockCompleteCloud struct {
+  Region  string `basicli:"region,r"`
+  Cluster string `basicli:"cluster"`
+}
+
+func (MockCompleteCloud) CompleteCluster(prefix string) []string {
+  return []string{"prod-eu", "prod-us", "staging"}
+}
+
+func (MockCompleteCloud) Status() error { return nil }
+
+func TestComplete(t *testing.T) {
+  var mc MockComplete
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&mc), "app")
+  assert.NilError(t, err)
+  rv := Concrete(reflect.ValueOf(&mc))
+  complete := func(words ...string) []string {
+    return complete(newConfig(nil), root, rv, words)
+  }
+
+  // Subcommands and flags at each level
+  assert.DeepEqual(t, complete(""), []string{"cloud", "c"})
+  assert.DeepEqual(t, complete("-"), []string{"--verbose", "-v"})
+  assert.DeepEqual(t, complete("-v", "cloud", ""), []string{"status"})
+  assert.DeepEqual(t, complete("c", "--r"), []string{"--region"})
+
+  // Bool flags consume an explicit value, as when parsing the command line
+  assert.DeepEqual(t, complete("-v", "true", ""), []string{"cloud", "c"})
+  assert.DeepEqual(t, complete("-v", "false", "cloud", ""), []string{"status"})
+
+  // Values come from `Complete<Field>` methods, filtered to the typed prefix
+  assert.DeepEqual(t, complete("cloud", "--cluster", "prod"), []string{"prod-eu", "prod-us"})
+  assert.DeepEqual(t, complete("cloud", "--cluster", "--region", ""), []string{"status"})
+
+  // Value flags without a completer produce nothing
+  assert.Check(t, len(complete("cloud", "-r", "")) == 0)
+}
+
+func TestCompleteBuiltin(t *testing.T) {
+  var mc MockComplete
+  var out strings.Builder
+
+  os.Args = []string{"app", "__complete", "cloud", "--cluster", "st"}
+  assert.NilError(t, Run(&mc, WithIO(&IO{Out: &out})))
+  assert.Equal(t, out.String(), "staging\n")
+}
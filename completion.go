@@ -0,0 +1,316 @@
+package basicli
+
+import (
+  "fmt"
+  "io"
+  "reflect"
+  "strings"
+)
+
+// cmdCompletion is the name of the built-in command which prints completion
+// scripts.
+const cmdCompletion = "completion"
+
+// Completion writes a static completion script for the command tree described
+// by `v` to `w`. `name` is the name of the executable the script completes and
+// `shell` may be one of `bash`, `zsh` or `fish`.
+//
+// At every level of the tree the script completes subcommand names and
+// aliases as well as flag names. Flags which take a value consume the
+// following word, so values are never mistaken for subcommands. The values
+// themselves are completed at runtime, by calling back into the executable's
+// hidden `__complete` command (see `Complete<Field>` methods).
+func Completion[P *T, T any](v P, name, shell string, w io.Writer, opts ...Option) error {
+  rt := reflect.TypeOf(v)
+  if indirect(rt).Kind() != reflect.Struct {
+    return fmt.Errorf("received non-struct type ['%T'] in call to Completion", v)
+  }
+  root, err := newCommand(newConfig(opts), rt, name)
+  if err != nil {
+    return err
+  }
+  return writeCompletion(w, root, shell)
+}
+
+func writeCompletion(w io.Writer, root *command, shell string) error {
+  states := completionStates(root)
+  switch shell {
+  case "bash":
+    writeBashCompletion(w, root.Name, states)
+  case "zsh":
+    writeZshCompletion(w, root.Name, states)
+  case "fish":
+    writeFishCompletion(w, root.Name, states)
+  default:
+    return fmt.Errorf("unsupported shell ['%s'], expected one of bash, zsh or fish", shell)
+  }
+  return nil
+}
+
+// runCompletionBuiltin prints the completion script for the shell named in
+// `args`.
+func runCompletionBuiltin(cfg *config, root *command, _ reflect.Value, args []string) error {
+  if len(args) != 1 {
+    return fmt.Errorf("expected a single shell argument to ['%s'], found %d", cmdCompletion, len(args))
+  }
+  return writeCompletion(cfg.io.Out, root, args[0])
+}
+
+// completionState is a single node of the command tree, flattened for use by
+// the generated scripts.
+type completionState struct {
+  ID          int
+  Transitions [][2]string // Word => state ID
+  ValueFlags  []string    // Flags (as typed) which consume the following word
+  Words       []string    // Candidate words at this state
+  Flags       []*flagDef
+  Dynamic     bool // Words other than flags are completed by the executable
+}
+
+// completionStates assigns every command in the tree a numeric state ID and
+// collects what the scripts need to know about each.
+func completionStates(root *command) []completionState {
+  ids := make(map[*command]int)
+  root.walk(func(cmd *command) { ids[cmd] = len(ids) })
+
+  states := make([]completionState, 0, len(ids))
+  root.walk(func(cmd *command) {
+    flags := cmd.visibleFlags()
+    state := completionState{ID: ids[cmd], Flags: flags, Dynamic: len(cmd.Args) > 0}
+    for _, child := range cmd.Commands {
+      for _, name := range append([]string{child.Name}, child.Aliases...) {
+        state.Transitions = append(state.Transitions, [2]string{name, fmt.Sprint(ids[child])})
+        state.Words = append(state.Words, name)
+      }
+    }
+    for _, flag := range flags {
+      for _, name := range flag.names() {
+        state.Words = append(state.Words, flagDisplay(name))
+        if flag.takesValue() {
+          state.ValueFlags = append(state.ValueFlags, flagDisplay(name))
+        }
+      }
+    }
+    states = append(states, state)
+  })
+  return states
+}
+
+func writeBashCompletion(w io.Writer, name string, states []completionState) {
+  fn := "_" + shellIdent(name)
+
+  fmt.Fprintf(w, "# bash completion for %s\n\n", name)
+  fmt.Fprintf(w, "%s() {\n", fn)
+  fmt.Fprint(w, "  local cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+  fmt.Fprint(w, "  local prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+  fmt.Fprint(w, "  local state=0 word i\n")
+  fmt.Fprint(w, "  for ((i = 1; i < COMP_CWORD; i++)); do\n")
+  fmt.Fprint(w, "    word=\"${COMP_WORDS[i]}\"\n")
+  writeShellStateCase(w, states)
+  fmt.Fprint(w, "  done\n\n")
+
+  // Flag values are completed by the executable itself
+  if patterns := valueFlagPatterns(states, shellQuote, "|"); len(patterns) > 0 {
+    fmt.Fprint(w, "  case \"${state}:${prev}\" in\n")
+    fmt.Fprintf(w, "    %s)\n", strings.Join(patterns, "|"))
+    fmt.Fprintf(w, "      mapfile -t COMPREPLY < <(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null)\n", cmdComplete)
+    fmt.Fprint(w, "      return ;;\n")
+    fmt.Fprint(w, "  esac\n\n")
+  }
+
+  // As are positional args, alongside any subcommands
+  if ids := dynamicStates(states); len(ids) > 0 {
+    fmt.Fprint(w, "  case \"${state}\" in\n")
+    fmt.Fprintf(w, "    %s)\n", strings.Join(ids, "|"))
+    fmt.Fprint(w, "      if [[ \"${cur}\" != -* ]]; then\n")
+    fmt.Fprintf(w, "        mapfile -t COMPREPLY < <(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD}\" 2>/dev/null)\n", cmdComplete)
+    fmt.Fprint(w, "        return\n")
+    fmt.Fprint(w, "      fi ;;\n")
+    fmt.Fprint(w, "  esac\n\n")
+  }
+
+  fmt.Fprint(w, "  local words\n")
+  fmt.Fprint(w, "  case \"${state}\" in\n")
+  for _, state := range states {
+    fmt.Fprintf(w, "    %d) words=%s ;;\n", state.ID, shellQuote(strings.Join(state.Words, " ")))
+  }
+  fmt.Fprint(w, "  esac\n")
+  fmt.Fprint(w, "  COMPREPLY=($(compgen -W \"${words}\" -- \"${cur}\"))\n")
+  fmt.Fprint(w, "}\n\n")
+  fmt.Fprintf(w, "complete -F %s %s\n", fn, shellQuote(name))
+}
+
+func writeZshCompletion(w io.Writer, name string, states []completionState) {
+  fn := "_" + shellIdent(name)
+
+  fmt.Fprintf(w, "#compdef %s\n\n", name)
+  fmt.Fprintf(w, "%s() {\n", fn)
+  fmt.Fprint(w, "  local state=0 word i\n")
+  fmt.Fprint(w, "  local -a candidates\n")
+  fmt.Fprint(w, "  for ((i = 2; i < CURRENT; i++)); do\n")
+  fmt.Fprint(w, "    word=\"${words[i]}\"\n")
+  writeShellStateCase(w, states)
+  fmt.Fprint(w, "  done\n\n")
+
+  // Flag values are completed by the executable itself
+  if patterns := valueFlagPatterns(states, shellQuote, "|"); len(patterns) > 0 {
+    fmt.Fprint(w, "  case \"${state}:${words[CURRENT-1]}\" in\n")
+    fmt.Fprintf(w, "    %s)\n", strings.Join(patterns, "|"))
+    fmt.Fprintf(w, "      candidates=(\"${(@f)$(\"${words[1]}\" %s \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")\n", cmdComplete)
+    fmt.Fprint(w, "      compadd -- \"${candidates[@]}\"\n")
+    fmt.Fprint(w, "      return ;;\n")
+    fmt.Fprint(w, "  esac\n\n")
+  }
+
+  // As are positional args, alongside any subcommands
+  if ids := dynamicStates(states); len(ids) > 0 {
+    fmt.Fprint(w, "  case \"${state}\" in\n")
+    fmt.Fprintf(w, "    %s)\n", strings.Join(ids, "|"))
+    fmt.Fprint(w, "      if [[ \"${words[CURRENT]}\" != -* ]]; then\n")
+    fmt.Fprintf(w, "        candidates=(\"${(@f)$(\"${words[1]}\" %s \"${(@)words[2,CURRENT]}\" 2>/dev/null)}\")\n", cmdComplete)
+    fmt.Fprint(w, "        compadd -- \"${candidates[@]}\"\n")
+    fmt.Fprint(w, "        return\n")
+    fmt.Fprint(w, "      fi ;;\n")
+    fmt.Fprint(w, "  esac\n\n")
+  }
+
+  fmt.Fprint(w, "  case \"${state}\" in\n")
+  for _, state := range states {
+    quoted := make([]string, len(state.Words))
+    for i, word := range state.Words {
+      quoted[i] = shellQuote(word)
+    }
+    fmt.Fprintf(w, "    %d) candidates=(%s) ;;\n", state.ID, strings.Join(quoted, " "))
+  }
+  fmt.Fprint(w, "  esac\n")
+  fmt.Fprint(w, "  compadd -- \"${candidates[@]}\"\n")
+  fmt.Fprint(w, "}\n\n")
+  fmt.Fprintf(w, "compdef %s %s\n", fn, shellQuote(name))
+}
+
+func writeFishCompletion(w io.Writer, name string, states []completionState) {
+  fn := "__" + shellIdent(name) + "_state"
+
+  fmt.Fprintf(w, "# fish completion for %s\n\n", name)
+  fmt.Fprintf(w, "function %s\n", fn)
+  fmt.Fprint(w, "  set -l state 0\n")
+  fmt.Fprint(w, "  set -l skip 0\n")
+  fmt.Fprint(w, "  for word in (commandline -opc)[2..-1]\n")
+  fmt.Fprint(w, "    if test $skip -eq 1\n")
+  fmt.Fprint(w, "      set skip 0\n")
+  fmt.Fprint(w, "      continue\n")
+  fmt.Fprint(w, "    end\n")
+  fmt.Fprint(w, "    switch \"$state:$word\"\n")
+  for _, state := range states {
+    for _, t := range state.Transitions {
+      fmt.Fprintf(w, "      case %s\n        set state %s\n", fishQuote(fmt.Sprintf("%d:%s", state.ID, t[0])), t[1])
+    }
+  }
+  for _, pattern := range valueFlagPatterns(states, fishQuote, " ") {
+    fmt.Fprintf(w, "      case %s\n        set skip 1\n", pattern)
+  }
+  fmt.Fprint(w, "    end\n")
+  fmt.Fprint(w, "  end\n")
+  fmt.Fprint(w, "  echo $state\n")
+  fmt.Fprint(w, "end\n\n")
+
+  fmt.Fprintf(w, "complete -c %s -f\n", fishQuote(name))
+  for _, state := range states {
+    cond := fishQuote(fmt.Sprintf("test (%s) = %d", fn, state.ID))
+    if state.Dynamic {
+      // Positional args are completed by the executable, alongside any
+      // subcommands
+      fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", fishQuote(name), cond, fishQuote(fmt.Sprintf(
+        "(%s %s (commandline -opc)[2..-1] (commandline -ct))", name, cmdComplete,
+      )))
+    } else {
+      for _, t := range state.Transitions {
+        fmt.Fprintf(w, "complete -c %s -n %s -a %s\n", fishQuote(name), cond, fishQuote(t[0]))
+      }
+    }
+    for _, flag := range state.Flags {
+      var b strings.Builder
+      for _, flagName := range append([]string{flag.Name}, flag.Aliases...) {
+        if len(flagName) == 1 {
+          fmt.Fprintf(&b, " -s %s", fishQuote(flagName))
+        } else {
+          fmt.Fprintf(&b, " -l %s", fishQuote(flagName))
+        }
+      }
+      if flag.takesValue() {
+        // Flag values are completed by the executable itself
+        fmt.Fprintf(&b, " -r -a %s", fishQuote(fmt.Sprintf(
+          "(%s %s (commandline -opc)[2..-1] (commandline -ct))", name, cmdComplete,
+        )))
+      }
+      fmt.Fprintf(w, "complete -c %s -n %s%s\n", fishQuote(name), cond, b.String())
+    }
+  }
+}
+
+// writeShellStateCase writes the `case` statement (shared by bash and zsh)
+// which advances the state on subcommand words and skips the values of value
+// flags.
+func writeShellStateCase(w io.Writer, states []completionState) {
+  fmt.Fprint(w, "    case \"${state}:${word}\" in\n")
+  for _, state := range states {
+    for _, t := range state.Transitions {
+      fmt.Fprintf(w, "      %s) state=%s ;;\n", shellQuote(fmt.Sprintf("%d:%s", state.ID, t[0])), t[1])
+    }
+  }
+  for _, pattern := range valueFlagPatterns(states, shellQuote, "|") {
+    fmt.Fprintf(w, "      %s) ((i++)) ;;\n", pattern)
+  }
+  fmt.Fprint(w, "    esac\n")
+}
+
+// valueFlagPatterns produces one `case` pattern per state with value flags,
+// matching `<state>:<flag>`, with alternatives quoted by `quote` and joined by
+// `sep`.
+func valueFlagPatterns(states []completionState, quote func(string) string, sep string) []string {
+  var patterns []string
+  for _, state := range states {
+    if len(state.ValueFlags) == 0 {
+      continue
+    }
+    alts := make([]string, len(state.ValueFlags))
+    for i, flag := range state.ValueFlags {
+      alts[i] = quote(fmt.Sprintf("%d:%s", state.ID, flag))
+    }
+    patterns = append(patterns, strings.Join(alts, sep))
+  }
+  return patterns
+}
+
+// dynamicStates lists the IDs of the states whose positional args are
+// completed by the executable.
+func dynamicStates(states []completionState) []string {
+  var ids []string
+  for _, state := range states {
+    if state.Dynamic {
+      ids = append(ids, fmt.Sprint(state.ID))
+    }
+  }
+  return ids
+}
+
+// shellQuote single-quotes `v` for use in bash or zsh.
+func shellQuote(v string) string {
+  return "'" + strings.ReplaceAll(v, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes `v` for use in fish.
+func fishQuote(v string) string {
+  v = strings.ReplaceAll(v, `\`, `\\`)
+  return "'" + strings.ReplaceAll(v, "'", `\'`) + "'"
+}
+
+// shellIdent produces a shell function name fragment from `v`.
+func shellIdent(v string) string {
+  return strings.Map(func(r rune) rune {
+    if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+      return r
+    }
+    return '_'
+  }, v)
+}
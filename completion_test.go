@@ -0,0 +1,107 @@
+package basicli
+
+import (
+  "os"
+  "os/exec"
+  "path/filepath"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockCompletion struct {
+  Verbose bool   `basicli:"verbose,v"`
+  Path    string `basicli:"path,p"`
+  Cloud   struct {
+    Region string `basicli:"region,r"`
+    Deploy struct {
+      DryRun bool `basicli:"dry-run"`
+    } `basicli:"deploy,d"`
+  } `basicli:"cloud,c"`
+}
+
+func TestCompletion(t *testing.T) {
+  // Every supported shell renders, unsupported shells are rejected
+  for _, shell := range []string{"bash", "zsh", "fish"} {
+    var b strings.Builder
+    assert.NilError(t, Completion(&MockCompletion{}, "app", shell, &b))
+    assert.Check(t, strings.Contains(b.String(), "'0:cloud'"), shell)
+  }
+  var b strings.Builder
+  assert.Error(t,
+    Completion(&MockCompletion{}, "app", "pwsh", &b),
+    "unsupported shell ['pwsh'], expected one of bash, zsh or fish",
+  )
+}
+
+type MockCompletionPointers struct {
+  Force *bool   `basicli:"force"`
+  Name  *string `basicli:"name"`
+}
+
+func TestCompletionValueFlags(t *testing.T) {
+  // Pointers to bools take no value, as when parsing the command line
+  root, err := newCommand(newConfig(nil), reflect.TypeFor[MockCompletionPointers](), "app")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, completionStates(root)[0].ValueFlags, []string{"--name"})
+  var b strings.Builder
+  assert.NilError(t, Completion(&MockCompletionPointers{}, "app", "fish", &b))
+  assert.Check(t, !strings.Contains(b.String(), "-l 'force' -r"), b.String())
+  assert.Check(t, strings.Contains(b.String(), "-l 'name' -r"), b.String())
+}
+
+func TestCompletionBash(t *testing.T) {
+  if _, err := exec.LookPath("bash"); err != nil {
+    t.Skip("bash is not available")
+  }
+
+  var b strings.Builder
+  assert.NilError(t, Completion(&MockCompletion{}, "app", "bash", &b))
+  script := filepath.Join(t.TempDir(), "app.bash")
+  assert.NilError(t, os.WriteFile(script, []byte(b.String()), 0o644))
+
+  complete := func(line string) string {
+    t.Helper()
+    words := strings.Fields(line)
+    if strings.HasSuffix(line, " ") {
+      words = append(words, "")
+    }
+    var quoted []string
+    for _, word := range words {
+      quoted = append(quoted, shellQuote(word))
+    }
+    // Stand in for the executable, echoing the args of `__complete` callbacks
+    cmd := exec.Command("bash", "-c",
+      "app() { echo \"$*\"; }"+
+        "; source "+shellQuote(script)+
+        "; COMP_WORDS=("+strings.Join(quoted, " ")+")"+
+        "; COMP_CWORD=$((${#COMP_WORDS[@]} - 1))"+
+        "; _app; echo \"${COMPREPLY[*]}\"",
+    )
+    out, err := cmd.Output()
+    assert.NilError(t, err)
+    return strings.TrimSpace(string(out))
+  }
+
+  assert.Equal(t, complete("app "), "cloud c --verbose -v --path -p")
+  assert.Equal(t, complete("app cl"), "cloud")
+  assert.Equal(t, complete("app c "), "deploy d --region -r")
+  // Bool flags don't consume the following word
+  assert.Equal(t, complete("app -v cloud d"), "deploy d")
+  // Value flags do, and their values are completed by the executable
+  assert.Equal(t, complete("app --path cloud "), "cloud c --verbose -v --path -p")
+  assert.Equal(t, complete("app cloud --region e"), "__complete cloud --region e")
+  assert.Equal(t, complete("app cloud deploy --"), "--dry-run")
+}
+
+func TestCompletionBuiltin(t *testing.T) {
+  var mc MockCompletion
+
+  os.Args = []string{"app", "completion"}
+  assert.Error(t, Dispatch(&mc), "expected a single shell argument to ['completion'], found 0")
+
+  os.Args = []string{"app", "completion", "bash"}
+  assert.NilError(t, Unmarshal(&mc))
+}
@@ -25,3 +25,33 @@ func Concrete(v reflect.Value) reflect.Value {
 
   return v
 }
+
+// allocate retrieves the struct underlying subcommand field `rv`, allocating
+// any nil pointers along the way where the field can be set.
+func allocate(rv reflect.Value) reflect.Value {
+  for rv.Kind() == reflect.Pointer {
+    if rv.IsNil() {
+      if !rv.CanSet() {
+        return reflect.Value{}
+      }
+      rv.Set(reflect.New(rv.Type().Elem()))
+    }
+    rv = rv.Elem()
+  }
+  return rv
+}
+
+// fieldByIndex retrieves the field of struct `rv` at `index`, which may be
+// promoted from an embedded struct, allocating any nil embedded struct pointers
+// along the way where they can be set.
+func fieldByIndex(rv reflect.Value, index []int) reflect.Value {
+  for i, x := range index {
+    if i > 0 {
+      if rv = allocate(rv); !rv.IsValid() {
+        return rv
+      }
+    }
+    rv = rv.Field(x)
+  }
+  return rv
+}
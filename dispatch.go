@@ -1,133 +1,140 @@
 package basicli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
-	"slices"
 	"strings"
-
-	"github.com/illbjorn/basicli/argv"
-	"github.com/illbjorn/basicli/tag"
 )
 
 // Dispatch recurses through nested structs described by the positional args
 // provided. Once all positional args have been accounted for, an `Exec` method
 // with signature `func()` is looked up and dispatched.
-func Dispatch[P *T, T any](v P) error {
-	args, _ := argv.Parse(os.Args[1:])
+//
+// The final positional arg may alternatively name a method on the struct
+// reached, with signature `func() error`, which is dispatched instead. Methods
+// returning anything but an `error`, such as `String() string`, and methods
+// promoted from embedded fields aren't subcommands.
+//
+// Methods may have either a value or a pointer receiver. Subcommands declared
+// as pointers may be left nil, and are allocated once addressed.
+//
+// Either method may also accept a `context.Context`, optionally followed by
+// either the positional args left over after the subcommand path (`[]string`)
+// or the command's streams (`*IO`):
+//
+//  func (Deploy) Exec(ctx context.Context, io *basicli.IO) error
+//
+// Every struct along the subcommand path may also declare the lifecycle hooks
+// `Before(ctx context.Context) error` and
+// `After(ctx context.Context, err error) error`, which run ahead of (root
+// first) and after (leaf first) the dispatched method respectively, all within
+// any registered middleware (see `Middleware`).
+//
+// A panic while running the command is recovered, and returned as a
+// `*PanicError`.
+func Dispatch[P *T, T any](v P, opts ...Option) (err error) {
+	defer recovered(&err, []string{filepath.Base(os.Args[0])})
+
+	cfg := newConfig(opts)
 
 	rv := Concrete(reflect.ValueOf(v))
 	if rv.Kind() != reflect.Struct {
 		return fmt.Errorf("received non-struct type ['%T'] in call to Dispatch", v)
 	}
 
-	return dispatch[T](rv, args)
+	// Serve built-in commands, unless the root declares its own
+	root, err := newCommand(cfg, rv.Type(), filepath.Base(os.Args[0]))
+	if err != nil {
+		return err
+	}
+	if run, ok := builtin(cfg, root, os.Args[1:]); ok {
+		return run(rv)
+	}
+
+	return dispatch(cfg, root, rv, os.Args[1:])
 }
 
-func dispatch[T any](rv reflect.Value, args []string) error {
-	switch {
-	case len(args) == 0:
-		// When we run out of args, dispatch the `Exec` method on the current
-		// reflected value, which should be a method
-		method := rv.MethodByName(methodExec)
-		if method.Kind() != reflect.Func {
-			return fmt.Errorf(
-				"failed to locate ['%s'] method on type ['%s']",
-				methodExec, rv.Type().Name(),
-			)
-		}
+func dispatch(cfg *config, root *command, rv reflect.Value, inputs []string) (err error) {
+	// Descend into the nested structs (subcommands) named by the positional args
+	l, err := parseLine(cfg, root, rv, inputs)
+	if err != nil {
+		return err
+	}
+	path, args := l.path, l.args
+	defer recovered(&err, leaf(path).cmd.Path)
+	if cmd := leaf(path).cmd; len(args) > 0 && !cmd.takesArgs() {
+		return unknownCommand(cmd, args[0])
+	}
 
-		// Call it!
-		outputs := method.Call(nil)
-		if len(outputs) == 0 {
-			return nil
-		}
-		maybeErr := outputs[0].Interface()
-		if maybeErr == nil {
-			return nil
-		}
-		return maybeErr.(error)
+	// Run the command we've landed on, surrounded by the lifecycle hooks of every
+	// struct along the way, within any middleware
+	handler, err := chain(cfg, structs(path), func(ctx context.Context, inv *Invocation) error {
+		return hooks(ctx, structs(path), func() error {
+			return execute(ctx, inv, leaf(path))
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return handler(cfg.ctx, invocation(cfg, path, args))
+}
 
-	case len(args) == 1:
-		// On the last (or first) positional arg, what we're after can either be:
-		//
-		// 1. An immediate method on our current reflected type
-		// 2. Another nested struct, under which we call the `Exec` method
-		//
-		// First, look for an immediate matching method
-		sought := args[0]
-		args = args[1:]
-		for i := range rv.NumMethod() {
-			method := rv.Method(i)
-			methodType := rv.Type().Method(i)
-			name := methodType.Name
-			if strings.EqualFold(sought, name) {
-				// Call it!
-				res := method.Call(nil)
-				// TODO: Produce a better error message here
-				if len(res) != 1 {
-					return fmt.Errorf(
-						"expected a single error return value, found ['%[1]T']: %[1]v",
-						res,
-					)
-				}
-				err := res[0]
-				if err.CanInterface() {
-					if err.IsNil() {
-						return nil
-					}
-					return res[0].Interface().(error)
-				}
-			}
-		}
+// execute calls the method leaf subcommand or `Exec` method of command frame
+// `f`, passing the context, positional args or IO where accepted.
+func execute(ctx context.Context, inv *Invocation, f frame) error {
+	cmd, rv := f.cmd, f.rv
 
-		// Alternatively, look through the methods and attempt to recurse again
-		// and catch an `Exec` method
-		for i := range rv.NumField() {
-			field := rv.Field(i)
-			fieldType := rv.Type().Field(i)
+	// We've landed on a method leaf subcommand
+	if len(cmd.Method) > 0 {
+		method := methodByName(rv, cmd.Method)
 
-			if strings.EqualFold(sought, fieldType.Name) {
-				return dispatch[T](field, args)
+		// Call it!
+		res, err := call(method, ctx, inv.Args, inv.IO)
+		if err != nil {
+			return err
+		}
+		// TODO: Produce a better error message here
+		if len(res) != 1 {
+			return fmt.Errorf(
+				"expected a single error return value, found ['%[1]T']: %[1]v",
+				res,
+			)
+		}
+		ret := res[0]
+		if ret.CanInterface() {
+			if ret.IsNil() {
+				return nil
 			}
+			return res[0].Interface().(error)
 		}
+		return nil
+	}
 
-		return fmt.Errorf("failed to identify leaf subcommand method target ['%s'] on type ['%s']", sought, rv.Type().Name())
-
-	default:
-		// With >1 positional args, we continue to descend into the nested structs
-		// (subcommands)
-		sought := args[0]
-		args = args[1:]
-		for i := range rv.NumField() {
-			field := rv.Field(i)
-			fieldType := rv.Type().Field(i)
-
-			// Look for a struct tag first
-			tagStr, ok := fieldType.Tag.Lookup(structTag)
-			if ok {
-				// Parse the tag string
-				tag := tag.Parse(tagStr)
-
-				// Compare what we're after against the primary tag name
-				if strings.EqualFold(sought, tag.Name) {
-					return dispatch[T](field, args)
-				}
-
-				// Iterate any aliases and check those as well
-				if slices.ContainsFunc(tag.Aliases, containsStrFold(sought)) {
-					return dispatch[T](field, args)
-				}
-			}
+	// Otherwise dispatch the `Exec` method on the struct we've landed on
+	method := methodByName(rv, methodExec)
+	if method.Kind() != reflect.Func {
+		return fmt.Errorf(
+			"failed to locate ['%s'] method on type ['%s']",
+			methodExec, rv.Type().Name(),
+		)
+	}
 
-			// Check for a direct match on field name
-			if strings.EqualFold(sought, fieldType.Name) {
-				return dispatch[T](field, args)
-			}
-		}
-		return fmt.Errorf("failed to identify nested composite type for subcommand ['%s']", sought)
+	// Call it!
+	outputs, err := call(method, ctx, inv.Args, inv.IO)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		return nil
+	}
+	maybeErr := outputs[0].Interface()
+	if maybeErr == nil {
+		return nil
 	}
+	return maybeErr.(error)
 }
 
 func containsStrFold(v string) func(sliceValue string) bool {
@@ -1,8 +1,10 @@
 package basicli
 
 import (
+  "context"
   "fmt"
   "os"
+  "sync"
   "testing"
 
   "gotest.tools/v3/assert"
@@ -31,13 +33,101 @@ func TestDispatch(t *testing.T) {
   os.Args = []string{"", "cmd", "hello"}
   assert.NilError(t, Dispatch(&md))
 
-  // Call the MockDispatch.Bad() method, expect an error since the function
-  // signature is illegal
+  // The MockDispatch.Bad() method isn't a command, since it doesn't return an
+  // error
   os.Args = []string{"", "bad"}
-  assert.Error(t, Dispatch(&md), "expected a single error return value, found ['[]reflect.Value']: []")
+  assert.Error(t, Dispatch(&md), "unknown command 'bad'")
 
   // Call the MockDispatch.GoodButAlsoBad() method, expect an error returned by
   // the function body naturally
   os.Args = []string{"", "goodbutalsobad"}
   assert.Error(t, Dispatch(&md), "oh no!")
 }
+
+type MockDispatchStringer struct {
+  sync.Mutex
+  Deploy MockDispatchStringerDeploy
+}
+
+type MockDispatchStringerDeploy struct {
+  Region string `basicli:"region"`
+}
+
+func (self MockDispatchStringerDeploy) String() string { return "deploy to " + self.Region }
+
+func (MockDispatchStringerDeploy) Exec() error { return nil }
+
+func (self *MockDispatchStringerDeploy) Reset() { self.Region = "" }
+
+func TestDispatchNonCommandMethods(t *testing.T) {
+  // Stringers, helpers returning nothing and promoted methods, such as those of
+  // an embedded mutex, are neither subcommands nor problems
+  assert.NilError(t, Validate(&MockDispatchStringer{}))
+  md, err := runArgs[MockDispatchStringer]("deploy", "--region", "eu")
+  assert.NilError(t, err)
+  assert.Equal(t, md.Deploy.String(), "deploy to eu")
+  _, err = runArgs[MockDispatchStringer]("deploy", "string")
+  assert.Error(t, err, "unknown command 'string'")
+  _, err = runArgs[MockDispatchStringer]("deploy", "reset")
+  assert.Error(t, err, "unknown command 'reset'")
+  _, err = runArgs[MockDispatchStringer]("lock")
+  assert.Error(t, err, "unknown command 'lock'")
+}
+
+type MockDispatchPointers struct {
+  Deploy  *MockDispatchDeploy
+  Destroy *MockDispatchDeploy
+  Tree    *MockDispatchTree
+}
+
+type MockDispatchDeploy struct {
+  Region string `basicli:"region"`
+  runs   int
+}
+
+func (self *MockDispatchDeploy) Before(ctx context.Context) error {
+  self.runs++
+  return nil
+}
+
+func (self *MockDispatchDeploy) Exec() error {
+  self.runs++
+  return nil
+}
+
+func (self *MockDispatchDeploy) Rollback() error {
+  self.Region = "rolled back"
+  return nil
+}
+
+type MockDispatchTree struct {
+  Child *MockDispatchTree
+  Name  string `basicli:"name"`
+}
+
+func (MockDispatchTree) Exec() error { return nil }
+
+func TestDispatchPointers(t *testing.T) {
+  // (good) Nil pointer subcommands are allocated only once selected, and
+  // methods with pointer receivers mutate the command itself
+  var md MockDispatchPointers
+  os.Args = []string{"", "deploy", "--region", "us"}
+  assert.NilError(t, Run(&md))
+  assert.Assert(t, md.Deploy != nil)
+  assert.Equal(t, md.Deploy.Region, "us")
+  assert.Equal(t, md.Deploy.runs, 2)
+  assert.Check(t, md.Destroy == nil)
+  assert.Check(t, md.Tree == nil)
+
+  // (good) Method leaf subcommands with pointer receivers
+  os.Args = []string{"", "destroy", "rollback"}
+  assert.NilError(t, Run(&md))
+  assert.Equal(t, md.Destroy.Region, "rolled back")
+
+  // (good) Recursive types end the tree rather than recursing forever
+  os.Args = []string{"", "tree", "--name", "x"}
+  assert.NilError(t, Run(&md))
+  assert.Equal(t, md.Tree.Name, "x")
+  os.Args = []string{"", "tree", "child"}
+  assert.Error(t, Run(&md), "unknown command 'child'")
+}
@@ -0,0 +1,265 @@
+package basicli
+
+import (
+  "cmp"
+  "fmt"
+  "io"
+  "os"
+  "path/filepath"
+  "reflect"
+  "slices"
+  "strings"
+)
+
+// docsIndex is the file name of the generated documentation index.
+const docsIndex = "index.md"
+
+// GenerateDocs writes Markdown documentation for the command tree described by
+// `v` to directory `dir`: one file per command, cross-linked to its parent and
+// subcommands, plus an `index.md` linking every command. `name` is used as the
+// root command name.
+//
+// The output depends only on the command tree, so it may be committed and
+// diffed in review.
+func GenerateDocs[P *T, T any](v P, name, dir string, opts ...Option) error {
+  rt := reflect.TypeOf(v)
+  if indirect(rt).Kind() != reflect.Struct {
+    return fmt.Errorf("received non-struct type ['%T'] in call to GenerateDocs", v)
+  }
+  root, err := newCommand(newConfig(opts), rt, name)
+  if err != nil {
+    return err
+  }
+
+  if err := os.MkdirAll(dir, 0o755); err != nil {
+    return err
+  }
+
+  // Index
+  var b strings.Builder
+  writeDocsIndex(&b, root)
+  if err := os.WriteFile(filepath.Join(dir, docsIndex), []byte(b.String()), 0o644); err != nil {
+    return err
+  }
+
+  // Commands
+  root.walk(func(cmd *command) {
+    if err != nil {
+      return
+    }
+    b.Reset()
+    writeDoc(&b, cmd)
+    err = os.WriteFile(filepath.Join(dir, docFile(cmd)), []byte(b.String()), 0o644)
+  })
+  return err
+}
+
+// writeDocsIndex renders a nested list of links to every command beneath (and
+// including) `root`.
+func writeDocsIndex(w io.Writer, root *command) {
+  fmt.Fprintf(w, "# %s\n\n", root.Name)
+  root.walk(func(cmd *command) {
+    indent := strings.Repeat("  ", len(cmd.Path)-1)
+    fmt.Fprintf(w, "%s- [%s](%s)\n", indent, strings.Join(cmd.Path, " "), docFile(cmd))
+  })
+}
+
+// writeDoc renders the documentation page for a single command.
+func writeDoc(w io.Writer, cmd *command) {
+  title := strings.Join(cmd.Path, " ")
+  fmt.Fprintf(w, "# %s\n\n", title)
+
+  if len(cmd.Help) > 0 {
+    fmt.Fprintf(w, "%s\n\n", cmd.Help)
+  }
+  if len(cmd.Aliases) > 0 {
+    fmt.Fprintf(w, "Aliases: %s\n\n", docCodeList(cmd.Aliases))
+  }
+
+  // Usage
+  fmt.Fprintf(w, "## Usage\n\n```\n%s\n```\n", usageLine(cmd))
+
+  // Subcommands
+  if len(cmd.Commands) > 0 {
+    fmt.Fprint(w, "\n## Commands\n\n")
+    fmt.Fprint(w, "| Command | Aliases | Description |\n")
+    fmt.Fprint(w, "| ------- | ------- | ----------- |\n")
+    for _, child := range cmd.Commands {
+      fmt.Fprintf(w, "| [%s](%s) | %s | %s |\n",
+        child.Name, docFile(child), docCodeList(child.Aliases), docText(child.Help),
+      )
+    }
+  }
+
+  // Flags
+  if len(cmd.Flags) > 0 {
+    fmt.Fprint(w, "\n## Flags\n")
+    for _, group := range flagGroups(cmd.Flags) {
+      if len(group[0].Group) > 0 {
+        fmt.Fprintf(w, "\n### %s\n", group[0].Group)
+      }
+      fmt.Fprint(w, "\n")
+      writeFlagTable(w, group)
+    }
+  }
+  if inherited := cmd.inherited(); len(inherited) > 0 {
+    fmt.Fprint(w, "\n## Inherited flags\n\n")
+    writeFlagTable(w, inherited)
+  }
+  if len(cmd.Rules) > 0 {
+    fmt.Fprint(w, "\n## Flag rules\n\n")
+    for _, rule := range cmd.Rules {
+      rule := rule.describe(func(flag string) string {
+        return docCode(flagDisplay(flag))
+      }, docCode)
+      fmt.Fprintf(w, "- %s%s\n", strings.ToUpper(rule[:1]), rule[1:])
+    }
+  }
+
+  // Positional args
+  if len(cmd.Args) > 0 {
+    fmt.Fprint(w, "\n## Arguments\n\n")
+    fmt.Fprint(w, "| Argument | Type | Default | Required | Description |\n")
+    fmt.Fprint(w, "| -------- | ---- | ------- | -------- | ----------- |\n")
+    for _, arg := range cmd.Args {
+      var def string
+      if arg.HasDefault {
+        def = docCode(arg.Default)
+      }
+      required := "no"
+      if cmd.argRequired(arg) {
+        required = "yes"
+      }
+      fmt.Fprintf(w, "| %s | %s | %s | %s | %s |\n",
+        docCode(argDisplay(arg)), docCode(arg.Field.Type.String()), def, required, docDescription(arg.Help, arg.Choices),
+      )
+    }
+  }
+
+  // Cross-links
+  fmt.Fprint(w, "\n## See also\n\n")
+  if len(cmd.Path) > 1 {
+    parent := cmd.Path[:len(cmd.Path)-1]
+    fmt.Fprintf(w, "- [%s](%s.md)\n", strings.Join(parent, " "), strings.Join(parent, "_"))
+  }
+  fmt.Fprintf(w, "- [Index](%s)\n", docsIndex)
+}
+
+// writeFlagTable renders a table describing `flags`.
+func writeFlagTable(w io.Writer, flags []*flagDef) {
+  fmt.Fprint(w, "| Flag | Type | Default | Env | Required | Description |\n")
+  fmt.Fprint(w, "| ---- | ---- | ------- | --- | -------- | ----------- |\n")
+  for _, flag := range flags {
+    names := make([]string, 0, len(flag.Aliases)+1)
+    for _, name := range flag.names() {
+      names = append(names, flagDisplay(name))
+    }
+    var def, env string
+    if flag.HasDefault {
+      def = docCode(flag.Default)
+    }
+    if len(flag.Env) > 0 {
+      env = docCode(flag.Env)
+    }
+    required := "no"
+    if flag.Required {
+      required = "yes"
+    }
+    fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %s |\n",
+      docCodeList(names), docCode(flag.Field.Type.String()), def, env, required, docDescription(flag.Help, flag.Choices),
+    )
+  }
+}
+
+// flagGroups splits `flags` by the embedded struct declaring them, those of the
+// command itself first, then each group in order of declaration.
+func flagGroups(flags []*flagDef) [][]*flagDef {
+  var groups [][]*flagDef
+  index := make(map[string]int)
+  for _, flag := range slices.SortedStableFunc(slices.Values(flags), func(a, b *flagDef) int {
+    return cmp.Compare(min(len(a.Group), 1), min(len(b.Group), 1))
+  }) {
+    i, ok := index[flag.Group]
+    if !ok {
+      i = len(groups)
+      index[flag.Group] = i
+      groups = append(groups, nil)
+    }
+    groups[i] = append(groups[i], flag)
+  }
+  return groups
+}
+
+// usageLine renders the synopsis of `cmd`, e.g. `app copy [flags] <src>`.
+func usageLine(cmd *command) string {
+  usage := strings.Join(cmd.Path, " ")
+  if len(cmd.Commands) > 0 {
+    usage += " [command]"
+  }
+  if len(cmd.visibleFlags()) > 0 {
+    usage += " [flags]"
+  }
+  if len(cmd.Args) > 0 {
+    usage += " " + argsUsage(cmd)
+  }
+  return usage
+}
+
+// argsUsage renders the positional args of `cmd` for a usage line, with
+// optional args in brackets.
+func argsUsage(cmd *command) string {
+  out := make([]string, len(cmd.Args))
+  for i, arg := range cmd.Args {
+    if cmd.argRequired(arg) {
+      out[i] = argDisplay(arg)
+    } else {
+      out[i] = "[" + argDisplay(arg) + "]"
+    }
+  }
+  return strings.Join(out, " ")
+}
+
+// argDisplay renders the name of a positional arg for usage lines.
+func argDisplay(arg *argDef) string {
+  if arg.Rest {
+    return "<" + arg.Name + "...>"
+  }
+  return "<" + arg.Name + ">"
+}
+
+// docFile produces the file name of the documentation page for `cmd`.
+func docFile(cmd *command) string {
+  return strings.Join(cmd.Path, "_") + ".md"
+}
+
+// docCode wraps `v` in a Markdown code span, escaping any table delimiters.
+func docCode(v string) string {
+  return "`" + docText(v) + "`"
+}
+
+// docText escapes any table delimiters in `v`.
+func docText(v string) string {
+  return strings.ReplaceAll(v, "|", `\|`)
+}
+
+// docDescription renders the description of a flag or positional arg: its help
+// text, followed by the values it accepts (if limited).
+func docDescription(help string, choices []string) string {
+  var parts []string
+  if len(help) > 0 {
+    parts = append(parts, docText(help))
+  }
+  if len(choices) > 0 {
+    parts = append(parts, "One of "+docCodeList(choices)+".")
+  }
+  return strings.Join(parts, " ")
+}
+
+// docCodeList renders each value of `vs` as a code span, comma-separated.
+func docCodeList(vs []string) string {
+  out := make([]string, len(vs))
+  for i, v := range vs {
+    out[i] = docCode(v)
+  }
+  return strings.Join(out, ", ")
+}
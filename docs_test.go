@@ -0,0 +1,76 @@
+package basicli
+
+import (
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockDocs struct {
+  Verbose bool `basicli:"verbose,v"`
+  Cloud   MockDocsCloud
+}
+
+type MockDocsCloud struct {
+  Region string `basicli:"region,r,env=APP_REGION,default=us|eu,help='Region, or regions'"`
+  Deploy struct {
+    Token string `basicli:"token,required=true"`
+  } `basicli:"deploy,d,help='Deploy, then verify'"`
+}
+
+func (MockDocsCloud) Status() error { return nil }
+
+func TestGenerateDocs(t *testing.T) {
+  dir := t.TempDir()
+  assert.NilError(t, GenerateDocs(&MockDocs{}, "app", dir))
+
+  entries, err := os.ReadDir(dir)
+  assert.NilError(t, err)
+  var names []string
+  for _, entry := range entries {
+    names = append(names, entry.Name())
+  }
+  assert.DeepEqual(t, names, []string{
+    "app.md", "app_cloud.md", "app_cloud_deploy.md", "app_cloud_status.md", "index.md",
+  })
+
+  read := func(name string) string {
+    b, err := os.ReadFile(filepath.Join(dir, name))
+    assert.NilError(t, err)
+    return string(b)
+  }
+
+  // Index links every command, nested by depth
+  assert.Equal(t, read("index.md"), strings.Join([]string{
+    "# app",
+    "",
+    "- [app](app.md)",
+    "  - [app cloud](app_cloud.md)",
+    "    - [app cloud deploy](app_cloud_deploy.md)",
+    "    - [app cloud status](app_cloud_status.md)",
+    "",
+  }, "\n"))
+
+  // Flag tables carry type, default, env and required status
+  cloud := read("app_cloud.md")
+  assert.Check(t, strings.Contains(cloud, "| [deploy](app_cloud_deploy.md) | `d` | Deploy, then verify |"), cloud)
+  assert.Check(t, strings.Contains(cloud,
+    "| `--region`, `-r` | `string` | `us\\|eu` | `APP_REGION` | no | Region, or regions |"), cloud)
+  assert.Check(t, strings.Contains(cloud, "- [app](app.md)"), cloud)
+
+  deploy := read("app_cloud_deploy.md")
+  assert.Check(t, strings.HasPrefix(deploy, "# app cloud deploy\n\nDeploy, then verify\n\n"), deploy)
+  assert.Check(t, strings.Contains(deploy, "| `--token` | `string` |  |  | yes |  |"), deploy)
+
+  // Output is deterministic
+  again := t.TempDir()
+  assert.NilError(t, GenerateDocs(&MockDocs{}, "app", again))
+  for _, name := range names {
+    b, err := os.ReadFile(filepath.Join(again, name))
+    assert.NilError(t, err)
+    assert.Equal(t, string(b), read(name))
+  }
+}
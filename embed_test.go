@@ -0,0 +1,106 @@
+package basicli
+
+import (
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockOutputOpts struct {
+  Format  string `basicli:"format,f,default=text"`
+  NoColor bool
+}
+
+type MockPagingOpts struct {
+  Limit int `basicli:"limit,min=1"`
+}
+
+type mockTraceOpts struct {
+  Trace bool
+}
+
+type MockEmbed struct {
+  List MockEmbedList
+  Get  MockEmbedGet
+}
+
+type MockEmbedList struct {
+  MockOutputOpts
+  *MockPagingOpts
+  mockTraceOpts
+  All bool
+}
+
+func (MockEmbedList) Exec() error { return nil }
+
+type MockEmbedGet struct {
+  MockOutputOpts `basicli:"output"`
+  Name           string `basicli:"name,pos=0"`
+}
+
+func (MockEmbedGet) Exec() error { return nil }
+
+type MockEmbedCollision struct {
+  MockOutputOpts
+  Fmt string `basicli:"f"`
+}
+
+type MockEmbedHidden struct {
+  *mockTraceOpts
+}
+
+func (MockEmbedHidden) Exec() error { return nil }
+
+func TestEmbed(t *testing.T) {
+  // (good) The fields of embedded structs are flags of the embedding command,
+  // including through pointers and unexported types
+  me, err := runArgs[MockEmbed]("list", "--format", "json", "--no-color", "--limit", "5", "--trace", "--all")
+  assert.NilError(t, err)
+  assert.Equal(t, me.List.Format, "json")
+  assert.Check(t, me.List.NoColor)
+  assert.Equal(t, me.List.Limit, 5)
+  assert.Check(t, me.List.Trace)
+  assert.Check(t, me.List.All)
+  me, err = runArgs[MockEmbed]("get", "-f", "yaml", "thing")
+  assert.NilError(t, err)
+  assert.Equal(t, me.Get.Format, "yaml")
+  assert.Equal(t, me.Get.Name, "thing")
+
+  // (good) Defaults and limits apply as usual, and embedded structs aren't
+  // subcommands
+  me, err = runArgs[MockEmbed]("get", "thing")
+  assert.NilError(t, err)
+  assert.Equal(t, me.Get.Format, "text")
+  _, err = runArgs[MockEmbed]("list", "--limit", "0")
+  assert.Error(t, err, "invalid value ['0'] for flag [limit]: must be at least 1")
+  _, err = runArgs[MockEmbed]("list", "mock-output-opts")
+  assert.Error(t, err, "unknown command 'mock-output-opts'")
+
+  // (bad) Name collisions with the embedding struct
+  assert.Error(t, Validate(&MockEmbedCollision{}), `found 1 problem(s) in command tree:
+  - ['app'] flags ['MockOutputOpts.Format'] and ['Fmt'] share the name ['-f']`)
+
+  // (bad) Unexported embedded pointers, which can't be allocated
+  assert.Error(t, Validate(&MockEmbedHidden{}), `found 1 problem(s) in command tree:
+  - ['app'] unexported embedded pointer ['mockTraceOpts'] can't be allocated`)
+  os.Args = []string{"app", "--trace"}
+  assert.Error(t, Unmarshal(&MockEmbedHidden{}), "unknown flag '--trace'")
+
+  // Embedded flags are documented as a group
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&MockEmbed{}), "app")
+  assert.NilError(t, err)
+  var b strings.Builder
+  writeDoc(&b, root.lookup("get"))
+  assert.Check(t, strings.Contains(b.String(), "## Flags\n\n### output\n\n| Flag |"), b.String())
+  b.Reset()
+  writeDoc(&b, root.lookup("list"))
+  doc := b.String()
+  assert.Check(t, strings.Contains(doc, "## Flags\n\n| Flag |"), doc)
+  assert.Check(t, strings.Contains(doc, "`--all`"), doc)
+  assert.Check(t, strings.Index(doc, "`--all`") < strings.Index(doc, "### MockOutputOpts"), doc)
+  assert.Check(t, strings.Contains(doc, "### MockPagingOpts\n\n| Flag |"), doc)
+  assert.Check(t, strings.Contains(doc, "### mockTraceOpts\n\n| Flag |"), doc)
+}
@@ -1,9 +1,249 @@
 package basicli
 
 import (
+  "context"
   "errors"
+  "fmt"
+  "os"
+  "runtime/debug"
+  "strings"
 )
 
 var ErrRequiredAndDefault = errors.New(
   "flag is marked required, required flags may not have default values",
 )
+
+// exitUsage is the exit code of a process given a command line which doesn't
+// fit its command tree.
+const exitUsage = 2
+
+// UsageError wraps an error caused by a command line which doesn't fit the
+// command tree: an unknown subcommand or flag, a missing required flag, an
+// unconvertible value and the like.
+type UsageError struct {
+  Err error
+}
+
+func (self *UsageError) Error() string {
+  return self.Err.Error()
+}
+
+func (self *UsageError) Unwrap() error {
+  return self.Err
+}
+
+// ExitCode reports the conventional exit code of a usage error, 2.
+func (self *UsageError) ExitCode() int {
+  return exitUsage
+}
+
+// usageError wraps `err` in a `*UsageError`.
+func usageError(err error) error {
+  return &UsageError{Err: err}
+}
+
+// invalidValue produces a `*UsageError` for value(s) `vs` provided for the flag
+// or positional arg (`kind`) `name` which failed conversion with `err`.
+func invalidValue(kind, name string, vs []string, err error) error {
+  return usageError(fmt.Errorf(
+    "invalid value ['%s'] for %s [%s]: %w", strings.Join(vs, "', '"), kind, name, err,
+  ))
+}
+
+// UnknownError reports a subcommand or flag which is not defined on the
+// command it was provided to, along with the closest defined names.
+type UnknownError struct {
+  Kind        string   // Either "command" or "flag"
+  Name        string   // The name as provided, without any leading dashes
+  Suggestions []string // The closest defined names, nearest first
+}
+
+func (self *UnknownError) Error() string {
+  var b strings.Builder
+  fmt.Fprintf(&b, "unknown %s '%s'", self.Kind, self.display(self.Name))
+  if len(self.Suggestions) > 0 {
+    fmt.Fprintf(&b, ", did you mean %s?", orList(self.Kind, self.Suggestions))
+  }
+  return b.String()
+}
+
+func (self *UnknownError) display(name string) string {
+  return displayName(self.Kind, name)
+}
+
+// AmbiguousError reports an abbreviated subcommand or flag name which is a
+// prefix of more than one defined name (see `WithPrefixMatching`).
+type AmbiguousError struct {
+  Kind       string   // Either "command" or "flag"
+  Name       string   // The name as provided, without any leading dashes
+  Candidates []string // Every defined name the prefix matches
+}
+
+func (self *AmbiguousError) Error() string {
+  return fmt.Sprintf(
+    "ambiguous %s '%s', could be %s",
+    self.Kind, displayName(self.Kind, self.Name), orList(self.Kind, self.Candidates),
+  )
+}
+
+// displayName renders `name` as it would be typed on the command line.
+func displayName(kind, name string) string {
+  if kind == "flag" {
+    return flagDisplay(name)
+  }
+  return name
+}
+
+// ChoiceError reports a value outside of the set a flag or positional arg
+// accepts (see `Enum`).
+type ChoiceError struct {
+  Value       string
+  Choices     []string
+  Suggestions []string // Closest choices to the value, if any are close
+}
+
+func (self *ChoiceError) Error() string {
+  msg := fmt.Sprintf("expected one of %s", orList("value", self.Choices))
+  if len(self.Suggestions) > 0 {
+    msg += fmt.Sprintf(", did you mean %s?", orList("value", self.Suggestions))
+  }
+  return msg
+}
+
+// ConstraintError reports a value of a flag or positional arg violating one of
+// its `min`, `max`, `minlen`, `maxlen` or `pattern` directives.
+type ConstraintError struct {
+  Kind      string   // Either "flag" or "arg"
+  Name      string   // Name of the flag or positional arg
+  Directive string   // The directive violated, such as "min"
+  Limit     string   // Value of the directive, such as "1"
+  Values    []string // The offending value, or every value when too many or few
+}
+
+func (self *ConstraintError) Error() string {
+  return fmt.Sprintf(
+    "invalid value ['%s'] for %s [%s]: %s",
+    strings.Join(self.Values, "', '"), self.Kind, self.Name, self.expected(),
+  )
+}
+
+// expected describes the constraint violated.
+func (self *ConstraintError) expected() string {
+  switch self.Directive {
+  case limitMin:
+    return "must be at least " + self.Limit
+  case limitMax:
+    return "must be at most " + self.Limit
+  case limitMinLen:
+    return "length must be at least " + self.Limit
+  case limitMaxLen:
+    return "length must be at most " + self.Limit
+  }
+  return fmt.Sprintf("must match pattern ['%s']", self.Limit)
+}
+
+// ArityError reports a number of positional args outside of the range a
+// command accepts.
+type ArityError struct {
+  Command []string // Path of the command, from the root
+  Min     int
+  Max     int // -1 when any number of args is accepted
+  Got     int
+}
+
+func (self *ArityError) Error() string {
+  var expected string
+  switch {
+  case self.Max < 0:
+    expected = fmt.Sprintf("at least %d", self.Min)
+  case self.Min == self.Max:
+    expected = fmt.Sprint(self.Min)
+  default:
+    expected = fmt.Sprintf("between %d and %d", self.Min, self.Max)
+  }
+  return fmt.Sprintf(
+    "'%s' expects %s positional args, found %d",
+    strings.Join(self.Command, " "), expected, self.Got,
+  )
+}
+
+// InterruptError reports a command which was cancelled because the process
+// received SIGINT or SIGTERM (see `Run`).
+type InterruptError struct {
+  Signal os.Signal
+  Err    error // The error returned by the command, if any
+}
+
+func (self *InterruptError) Error() string {
+  if self.Err != nil && !errors.Is(self.Err, context.Canceled) {
+    return fmt.Sprintf("interrupted by signal [%s]: %s", self.Signal, self.Err)
+  }
+  return fmt.Sprintf("interrupted by signal [%s]", self.Signal)
+}
+
+func (self *InterruptError) Unwrap() error {
+  return self.Err
+}
+
+// ExitCode reports the conventional exit code of an interrupted process, 130.
+func (self *InterruptError) ExitCode() int {
+  return exitInterrupted
+}
+
+// PanicError reports a panic recovered while running a command, whether raised
+// by the command itself or by basicli (see `Run` and `Dispatch`).
+type PanicError struct {
+  Command []string // Names of the commands addressed, from the root
+  Value   any      // The value passed to `panic`
+  Stack   []byte   // The stack of the panicking goroutine
+}
+
+func (self *PanicError) Error() string {
+  return fmt.Sprintf("panic in ['%s']: %v", strings.Join(self.Command, " "), self.Value)
+}
+
+// Unwrap returns the panic value, when it's an error.
+func (self *PanicError) Unwrap() error {
+  err, _ := self.Value.(error)
+  return err
+}
+
+// recovered recovers from any panic in progress, replacing `*err` with a
+// `*PanicError` attributed to `command`. It must be deferred directly.
+func recovered(err *error, command []string) {
+  if v := recover(); v != nil {
+    *err = &PanicError{Command: command, Value: v, Stack: debug.Stack()}
+  }
+}
+
+// orList renders `names` quoted, as a list ending in "or".
+func orList(kind string, names []string) string {
+  quoted := make([]string, len(names))
+  for i, name := range names {
+    quoted[i] = "'" + displayName(kind, name) + "'"
+  }
+  if len(quoted) < 2 {
+    return strings.Join(quoted, "")
+  }
+  return strings.Join(quoted[:len(quoted)-1], ", ") + " or " + quoted[len(quoted)-1]
+}
+
+// unknownCommand produces an `*UnknownError` (wrapped in a `*UsageError`) for
+// subcommand `name` of `cmd`.
+func unknownCommand(cmd *command, name string) error {
+  return usageError(&UnknownError{
+    Kind:        "command",
+    Name:        name,
+    Suggestions: suggest(name, commandNames(cmd), true),
+  })
+}
+
+// unknownFlag produces an `*UnknownError` (wrapped in a `*UsageError`) for flag
+// `name` of `cmd`.
+func unknownFlag(cmd *command, name string) error {
+  return usageError(&UnknownError{
+    Kind:        "flag",
+    Name:        name,
+    Suggestions: suggest(name, flagNames(cmd), false),
+  })
+}
@@ -0,0 +1,72 @@
+package basicli
+
+import (
+  "context"
+  "fmt"
+  "reflect"
+)
+
+var (
+  typeContext = reflect.TypeFor[context.Context]()
+  typeArgs    = reflect.TypeFor[[]string]()
+  typeIO      = reflect.TypeFor[*IO]()
+  typeError   = reflect.TypeFor[error]()
+)
+
+// call invokes command method `method`, supplying whichever of `ctx`, the
+// positional `args` and `stdio` its parameters ask for. Supported parameter
+// lists are:
+//
+//  ()
+//  (ctx context.Context)
+//  (ctx context.Context, args []string)
+//  (ctx context.Context, io *IO)
+func call(method reflect.Value, ctx context.Context, args []string, stdio *IO) ([]reflect.Value, error) {
+  mt := method.Type()
+  if !supportedParams(mt) {
+    return nil, fmt.Errorf("unsupported command method parameters ['%s']", mt)
+  }
+
+  in := make([]reflect.Value, mt.NumIn())
+  for i := range in {
+    switch mt.In(i) {
+    case typeContext:
+      in[i] = reflect.ValueOf(&ctx).Elem()
+    case typeArgs:
+      in[i] = reflect.ValueOf(args)
+    case typeIO:
+      in[i] = reflect.ValueOf(stdio)
+    }
+  }
+  return method.Call(in), nil
+}
+
+// supportedParams reports whether `call` knows how to supply the parameters of
+// method type `mt`.
+func supportedParams(mt reflect.Type) bool {
+  if mt.IsVariadic() {
+    return false
+  }
+  switch mt.NumIn() {
+  case 0:
+    return true
+  case 1:
+    return mt.In(0) == typeContext
+  case 2:
+    return mt.In(0) == typeContext && (mt.In(1) == typeArgs || mt.In(1) == typeIO)
+  }
+  return false
+}
+
+// methodByName looks up method `name` on struct `rv`, including methods with a
+// pointer receiver when `rv` is addressable. The zero Value is returned when
+// there is no such method.
+func methodByName(rv reflect.Value, name string) reflect.Value {
+  if !rv.IsValid() {
+    return reflect.Value{}
+  }
+  if rv.CanAddr() {
+    rv = rv.Addr()
+  }
+  return rv.MethodByName(name)
+}
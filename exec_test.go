@@ -0,0 +1,96 @@
+package basicli
+
+import (
+  "context"
+  "fmt"
+  "os"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type ctxKey struct{}
+
+type MockExec struct {
+  Ctx  MockExecCtx
+  Args MockExecArgs
+  IO   MockExecIO
+}
+
+type MockExecCtx struct{}
+
+func (MockExecCtx) Exec(ctx context.Context) error {
+  if ctx.Value(ctxKey{}) != "value" {
+    return fmt.Errorf("missing context value")
+  }
+  return nil
+}
+
+type MockExecArgs struct{}
+
+func (MockExecArgs) Exec(ctx context.Context, args []string) error {
+  return fmt.Errorf("%s", strings.Join(args, ","))
+}
+
+func (MockExecArgs) Echo(ctx context.Context, args []string) error {
+  return fmt.Errorf("echo %s", strings.Join(args, ","))
+}
+
+type MockExecIO struct {
+  Name string `basicli:"name"`
+}
+
+func (self MockExecIO) Exec(ctx context.Context, io *IO) error {
+  fmt.Fprintf(io.Out, "hello, %s", self.Name)
+  return nil
+}
+
+func (MockExecIO) Greet(ctx context.Context, io *IO) error {
+  fmt.Fprint(io.Err, "greetings")
+  return nil
+}
+
+type MockExecUnsupported struct {
+  Bad MockExecBad
+}
+
+type MockExecBad struct{}
+
+func (MockExecBad) Exec(name string) error { return nil }
+
+func TestExecSignatures(t *testing.T) {
+  var me MockExec
+  var out, errOut strings.Builder
+  opts := []Option{
+    WithContext(context.WithValue(context.Background(), ctxKey{}, "value")),
+    WithIO(&IO{Out: &out, Err: &errOut}),
+  }
+
+  // Context
+  os.Args = []string{"", "ctx"}
+  assert.NilError(t, Run(&me, opts...))
+
+  // Context and leftover positional args
+  os.Args = []string{"", "args", "a", "b"}
+  assert.Error(t, Run(&me, opts...), "a,b")
+  os.Args = []string{"", "args", "echo", "a"}
+  assert.Error(t, Run(&me, opts...), "echo a")
+
+  // Commands accepting no args still reject them
+  os.Args = []string{"", "ctx", "a"}
+  assert.Error(t, Run(&me, opts...), "unknown command 'a'")
+
+  // Context and IO, for both `Exec` and method leaf subcommands
+  os.Args = []string{"", "io", "--name", "world"}
+  assert.NilError(t, Run(&me, opts...))
+  assert.Equal(t, out.String(), "hello, world")
+  os.Args = []string{"", "io", "greet"}
+  assert.NilError(t, Run(&me, opts...))
+  assert.Equal(t, errOut.String(), "greetings")
+
+  // Unsupported parameters, caught by validation ahead of dispatch
+  os.Args = []string{"app", "bad"}
+  assert.Error(t, Dispatch(&MockExecUnsupported{}, opts...), "unsupported command method parameters ['func(string) error']")
+  assert.ErrorContains(t, Run(&MockExecUnsupported{}, opts...), "['app bad'] method ['Exec'] has unsupported signature ['func(string) error']")
+}
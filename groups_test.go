@@ -0,0 +1,103 @@
+package basicli
+
+import (
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockGroups struct {
+  DB struct {
+    Host    string `basicli:"host,h,default=localhost"`
+    Port    int    `basicli:"port,min=1"`
+    User    string `basicli:"user,env=PGUSER"`
+    Replica struct {
+      Host string
+    }
+  } `basicli:"prefix=db"`
+  Cache *MockGroupsCache `basicli:"prefix=cache"`
+  Serve MockGroupsServe
+}
+
+type MockGroupsCache struct {
+  MockOutputOpts
+  TTL int `basicli:"ttl"`
+}
+
+type MockGroupsServe struct{}
+
+func (MockGroupsServe) Exec() error { return nil }
+
+type MockGroupsConn struct {
+  Host string
+}
+
+type MockGroupsSelector struct {
+  DB    MockGroupsConn `basicli:"prefix=db"`
+  Cache MockGroupsConn `basicli:"prefix=cache"`
+  Host  string         `basicli:"bind"`
+}
+
+func (MockGroupsSelector) Exec() error { return nil }
+
+type MockGroupsShadow struct {
+  DB    MockGroupsConn `basicli:"prefix=db"`
+  Other string         `basicli:"DB.Host"`
+}
+
+func (MockGroupsShadow) Exec() error { return nil }
+
+func TestGroups(t *testing.T) {
+  // (good) Fields of option groups are dotted flags, not subcommands
+  mg, err := runArgs[MockGroups]("--db.port", "5432", "--db.h", "db1", "--db.replica.host", "db2", "--cache.ttl", "60", "--cache.format", "json", "serve")
+  assert.NilError(t, err)
+  assert.Equal(t, mg.DB.Host, "db1")
+  assert.Equal(t, mg.DB.Port, 5432)
+  assert.Equal(t, mg.DB.Replica.Host, "db2")
+  assert.Equal(t, mg.Cache.TTL, 60)
+  assert.Equal(t, mg.Cache.Format, "json")
+  mg, err = runArgs[MockGroups]("serve")
+  assert.NilError(t, err)
+  assert.Equal(t, mg.DB.Host, "localhost")
+  _, err = runArgs[MockGroups]("db", "serve")
+  assert.Error(t, err, "unknown command 'db'")
+  _, err = runArgs[MockGroups]("--db.port", "0", "serve")
+  assert.Error(t, err, "invalid value ['0'] for flag [db.port]: must be at least 1")
+
+  // (good) Environment variables derive from the flag name, unless declared
+  t.Setenv("DB_HOST", "db3")
+  t.Setenv("DB_REPLICA_HOST", "db4")
+  t.Setenv("PGUSER", "admin")
+  mg, err = runArgs[MockGroups]("serve")
+  assert.NilError(t, err)
+  assert.Equal(t, mg.DB.Host, "db3")
+  assert.Equal(t, mg.DB.Replica.Host, "db4")
+  assert.Equal(t, mg.DB.User, "admin")
+  assert.NilError(t, Validate(&MockGroups{}))
+
+  // (good) Go names of group fields are qualified by the group
+  ms, err := runArgs[MockGroupsSelector]("--Host", "a", "--DB.Host", "b", "--Cache.Host", "c")
+  assert.NilError(t, err)
+  assert.Equal(t, ms.Host, "a")
+  assert.Equal(t, ms.DB.Host, "b")
+  assert.Equal(t, ms.Cache.Host, "c")
+  assert.NilError(t, Validate(&MockGroupsSelector{}))
+
+  // (bad) Names shadowing the Go name of another flag
+  assert.Error(t, Validate(&MockGroupsShadow{}), `found 1 problem(s) in command tree:
+  - ['app'] flags ['db.Host'] and ['Other'] share the name ['--DB.Host']`)
+
+  // Option groups are documented as groups
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&MockGroups{}), "app")
+  assert.NilError(t, err)
+  var b strings.Builder
+  writeDoc(&b, root)
+  doc := b.String()
+  assert.Check(t, strings.Contains(doc, "### db\n\n| Flag |"), doc)
+  assert.Check(t, strings.Contains(doc, "| `--db.host`, `--db.h` | `string` | `localhost` | `DB_HOST` |"), doc)
+  assert.Check(t, strings.Contains(doc, "### db.replica\n\n"), doc)
+  assert.Check(t, strings.Contains(doc, "### cache.MockOutputOpts\n\n"), doc)
+  assert.Check(t, strings.Contains(doc, "`CACHE_FORMAT`"), doc)
+}
@@ -0,0 +1,99 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "reflect"
+)
+
+// hooks runs `fn` surrounded by the lifecycle hooks of the structs of `path`.
+// Hooks are optional methods declared on any struct along the subcommand path:
+//
+//  func (Cloud) Before(ctx context.Context) error
+//  func (Cloud) After(ctx context.Context, err error) error
+//
+// When dispatching `root -> cloud -> deploy`, `Before` runs on root, cloud then
+// deploy, ahead of `fn`. `After` then runs in reverse order on every struct
+// whose `Before` succeeded (or which declares none), receiving the error so far
+// - even when `fn`, or a deeper `Before`, failed. The error returned by each
+// `After` replaces the one it received.
+func hooks(ctx context.Context, path []frame, fn func() error) error {
+  var err error
+  var entered int
+  for _, f := range path {
+    if err = before(ctx, f.rv); err != nil {
+      break
+    }
+    entered++
+  }
+  if err == nil {
+    err = fn()
+  }
+  for i := entered - 1; i >= 0; i-- {
+    err = after(ctx, path[i].rv, err)
+  }
+  return err
+}
+
+// before calls the `Before` hook on struct `rv`, if declared.
+func before(ctx context.Context, rv reflect.Value) error {
+  method := methodByName(rv, methodBefore)
+  if !method.IsValid() {
+    return nil
+  }
+  fn, ok := method.Interface().(func(context.Context) error)
+  if !ok {
+    return hookSignatureError(rv, method)
+  }
+  return fn(ctx)
+}
+
+// after calls the `After` hook on struct `rv`, if declared, passing it `err`.
+func after(ctx context.Context, rv reflect.Value, err error) error {
+  method := methodByName(rv, methodAfter)
+  if !method.IsValid() {
+    return err
+  }
+  fn, ok := method.Interface().(func(context.Context, error) error)
+  if !ok {
+    return hookSignatureError(rv, method)
+  }
+  return fn(ctx, err)
+}
+
+// validated calls the `Validate` hook on the structs of `path`, root first,
+// once their flags and positional args are bound:
+//
+//  func (Deploy) Validate() error
+//
+// `Validate` runs ahead of any middleware, `Before` hook or command, so it can
+// reject a combination of values before anything acts on them. The first error
+// returned is a usage error.
+func validated(path []frame) error {
+  for _, f := range path {
+    method := methodByName(f.rv, methodValidate)
+    if !method.IsValid() {
+      continue
+    }
+    fn, ok := method.Interface().(func() error)
+    if !ok {
+      return hookSignatureError(f.rv, method)
+    }
+    if err := fn(); err != nil {
+      var usage *UsageError
+      if errors.As(err, &usage) {
+        return err
+      }
+      return usageError(err)
+    }
+  }
+  return nil
+}
+
+func hookSignatureError(rv reflect.Value, method reflect.Value) error {
+  return fmt.Errorf(
+    "found unsupported hook signature ['%s'] on type ['%s']",
+    method.Type(), rv.Type().Name(),
+  )
+}
@@ -0,0 +1,120 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "os"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+var mockHooksTrace []string
+
+type MockHooks struct {
+  Cloud MockHooksCloud
+}
+
+func (MockHooks) Before(ctx context.Context) error {
+  mockHooksTrace = append(mockHooksTrace, "before root")
+  return nil
+}
+
+func (MockHooks) After(ctx context.Context, err error) error {
+  mockHooksTrace = append(mockHooksTrace, fmt.Sprintf("after root (%v)", err))
+  return err
+}
+
+type MockHooksCloud struct {
+  Deploy MockHooksDeploy
+}
+
+func (MockHooksCloud) Before(ctx context.Context) error {
+  mockHooksTrace = append(mockHooksTrace, "before cloud")
+  return nil
+}
+
+func (MockHooksCloud) Status() error {
+  mockHooksTrace = append(mockHooksTrace, "status")
+  return nil
+}
+
+type MockHooksDeploy struct {
+  DenyAuth bool   `basicli:"deny-auth"`
+  Target   string `basicli:"target"`
+}
+
+func (self MockHooksDeploy) Validate() error {
+  mockHooksTrace = append(mockHooksTrace, "validate deploy")
+  if self.Target == "prod" && self.DenyAuth {
+    return fmt.Errorf("[--deny-auth] can't be used with target [prod]")
+  }
+  return nil
+}
+
+func (self MockHooksDeploy) Before(ctx context.Context) error {
+  mockHooksTrace = append(mockHooksTrace, "before deploy")
+  if self.DenyAuth {
+    return fmt.Errorf("unauthorized")
+  }
+  return nil
+}
+
+func (MockHooksDeploy) After(ctx context.Context, err error) error {
+  mockHooksTrace = append(mockHooksTrace, fmt.Sprintf("after deploy (%v)", err))
+  return fmt.Errorf("wrapped: %w", err)
+}
+
+func (MockHooksDeploy) Exec() error {
+  mockHooksTrace = append(mockHooksTrace, "deploy")
+  return fmt.Errorf("failed")
+}
+
+func TestHooks(t *testing.T) {
+  run := func(args ...string) error {
+    mockHooksTrace = nil
+    os.Args = append([]string{""}, args...)
+    return Run(&MockHooks{})
+  }
+
+  // Before runs root first, After leaf first, even when the command fails
+  err := run("cloud", "deploy")
+  assert.Error(t, err, "wrapped: failed")
+  assert.DeepEqual(t, mockHooksTrace, []string{
+    "validate deploy",
+    "before root", "before cloud", "before deploy",
+    "deploy",
+    "after deploy (failed)", "after root (wrapped: failed)",
+  })
+
+  // A failing Before skips the command, and its own After
+  err = run("cloud", "deploy", "--deny-auth")
+  assert.Error(t, err, "unauthorized")
+  assert.DeepEqual(t, mockHooksTrace, []string{
+    "validate deploy",
+    "before root", "before cloud", "before deploy",
+    "after root (unauthorized)",
+  })
+
+  // A failing Validate is a usage error, raised before any hook runs
+  err = run("cloud", "deploy", "--deny-auth", "--target", "prod")
+  assert.Error(t, err, "[--deny-auth] can't be used with target [prod]")
+  var usage *UsageError
+  assert.Check(t, errors.As(err, &usage))
+  assert.Equal(t, usage.ExitCode(), 2)
+  assert.DeepEqual(t, mockHooksTrace, []string{"validate deploy"})
+
+  // Method leaf subcommands run within their struct's hooks, once
+  err = run("cloud", "status")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, mockHooksTrace, []string{
+    "before root", "before cloud", "status", "after root (<nil>)",
+  })
+
+  // Hooks aren't subcommands
+  err = run("cloud", "before")
+  assert.Error(t, err, "unknown command 'before'")
+  err = run("cloud", "deploy", "validate")
+  assert.Error(t, err, "unknown command 'validate'")
+}
@@ -0,0 +1,22 @@
+package basicli
+
+import (
+  "io"
+  "os"
+)
+
+// IO carries the streams a command should read from and write to, in place of
+// `os.Stdin`, `os.Stdout` and `os.Stderr`.
+//
+// A command receives it by declaring `Exec(ctx context.Context, io *IO) error`,
+// which keeps its output testable (see `WithIO`).
+type IO struct {
+  In  io.Reader
+  Out io.Writer
+  Err io.Writer
+}
+
+// stdio produces an `IO` backed by the process' standard streams.
+func stdio() *IO {
+  return &IO{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+}
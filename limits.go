@@ -0,0 +1,169 @@
+package basicli
+
+import (
+  "cmp"
+  "reflect"
+  "regexp"
+  "strconv"
+  "unicode/utf8"
+
+  "github.com/illbjorn/basicli/tag"
+)
+
+// Directives constraining the values of a flag or positional arg
+const (
+  limitMin     = "min"
+  limitMax     = "max"
+  limitMinLen  = "minlen"
+  limitMaxLen  = "maxlen"
+  limitPattern = "pattern"
+)
+
+// limit is a constraint on the values of a flag or positional arg, checked once
+// they're converted to the type of its field.
+type limit struct {
+  Directive string
+  Value     string
+  pattern   *regexp.Regexp
+}
+
+// limits produces the constraints of tag `t`, which `tag.ParseStrict` has
+// already checked are well-formed.
+func limits(t tag.Tag) []limit {
+  var ls []limit
+  for _, l := range t.Limits {
+    ls = append(ls, limit{Directive: l.Directive, Value: l.Value})
+    if l.Directive == limitPattern {
+      ls[len(ls)-1].pattern = regexp.MustCompile(l.Value)
+    }
+  }
+  return ls
+}
+
+func (self limit) String() string {
+  return self.Directive + "=" + self.Value
+}
+
+// applies reports whether the limit can constrain fields of type `rt`.
+//
+// Lengths constrain strings, and the number of values of slices. Bounds and
+// patterns constrain integers and strings respectively, or each value of a
+// slice of them.
+func (self limit) applies(rt reflect.Type) bool {
+  rt = indirect(rt)
+  if self.Directive == limitMinLen || self.Directive == limitMaxLen {
+    return rt.Kind() == reflect.String || rt.Kind() == reflect.Slice
+  }
+  if rt.Kind() == reflect.Slice {
+    rt = rt.Elem()
+  }
+  switch rt.Kind() {
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    _, err := strconv.ParseInt(self.Value, 10, 64)
+    return self.Directive != limitPattern && err == nil
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    _, err := strconv.ParseUint(self.Value, 10, 64)
+    return self.Directive != limitPattern && err == nil
+  case reflect.String:
+    return self.Directive == limitPattern
+  }
+  return false
+}
+
+// violated reports whether converted value `rv` violates the limit, along with
+// the offending value. Limits which don't apply to `rv` are never violated.
+func (self limit) violated(rv reflect.Value) ([]string, bool) {
+  rv = Concrete(rv)
+
+  // Lengths
+  if self.Directive == limitMinLen || self.Directive == limitMaxLen {
+    var n int
+    switch rv.Kind() {
+    case reflect.String:
+      n = utf8.RuneCountInString(rv.String())
+    case reflect.Slice:
+      n = rv.Len()
+    default:
+      return nil, false
+    }
+    bound, _ := strconv.Atoi(self.Value)
+    if self.Directive == limitMinLen && n < bound || self.Directive == limitMaxLen && n > bound {
+      return display(rv), true
+    }
+    return nil, false
+  }
+
+  // Slices are constrained value by value
+  if rv.Kind() == reflect.Slice {
+    for i := range rv.Len() {
+      if vs, bad := self.violated(rv.Index(i)); bad {
+        return vs, true
+      }
+    }
+    return nil, false
+  }
+
+  var order int
+  switch rv.Kind() {
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    bound, err := strconv.ParseInt(self.Value, 10, 64)
+    if err != nil {
+      return nil, false
+    }
+    order = cmp.Compare(rv.Int(), bound)
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    bound, err := strconv.ParseUint(self.Value, 10, 64)
+    if err != nil {
+      return nil, false
+    }
+    order = cmp.Compare(rv.Uint(), bound)
+  case reflect.String:
+    if self.Directive == limitPattern && !self.pattern.MatchString(rv.String()) {
+      return display(rv), true
+    }
+    return nil, false
+  default:
+    return nil, false
+  }
+  if self.Directive == limitMin && order < 0 || self.Directive == limitMax && order > 0 {
+    return display(rv), true
+  }
+  return nil, false
+}
+
+// constrain checks the value of field `rv`, bound to the flag or positional arg
+// (`kind`) `name`, against each of `limits`.
+func constrain(kind, name string, limits []limit, rv reflect.Value) error {
+  for _, l := range limits {
+    if vs, bad := l.violated(rv); bad {
+      return usageError(&ConstraintError{
+        Kind:      kind,
+        Name:      name,
+        Directive: l.Directive,
+        Limit:     l.Value,
+        Values:    vs,
+      })
+    }
+  }
+  return nil
+}
+
+// display produces the values held by `rv`, as they'd be provided.
+func display(rv reflect.Value) []string {
+  if rv.Kind() == reflect.Slice {
+    vs := make([]string, rv.Len())
+    for i := range vs {
+      vs[i] = display(rv.Index(i))[0]
+    }
+    return vs
+  }
+  switch rv.Kind() {
+  case reflect.Bool:
+    return []string{strconv.FormatBool(rv.Bool())}
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    return []string{strconv.FormatInt(rv.Int(), 10)}
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    return []string{strconv.FormatUint(rv.Uint(), 10)}
+  }
+  return []string{rv.String()}
+}
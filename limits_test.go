@@ -0,0 +1,73 @@
+package basicli
+
+import (
+  "errors"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockLimits struct {
+  Port  int      `basicli:"port,min=1,max=65535,env=BASICLI_TEST_PORT"`
+  Name  string   `basicli:"name,minlen=3,maxlen=63,pattern='^[a-z-]+$'"`
+  Tags  []string `basicli:"tag,maxlen=2,pattern=^[a-z]+$"`
+  Sizes []uint   `basicli:"size,max=10"`
+  Count int      `basicli:"count,pos=0,min=1,default=1"`
+}
+
+func (MockLimits) Exec() error { return nil }
+
+type MockLimitsBad struct {
+  Port  int    `basicli:"port,min=1,default=0"`
+  Ratio int    `basicli:"ratio,max=0.5"`
+  Debug bool   `basicli:"debug,minlen=1"`
+  Name  string `basicli:"name,pos=0,max=3"`
+}
+
+func TestLimits(t *testing.T) {
+  // (good) Values within their limits, and unset flags aren't checked
+  ml, err := runArgs[MockLimits]("--port", "8080", "--name", "api-gateway", "--tag", "a", "--tag", "b", "--size", "10", "3")
+  assert.NilError(t, err)
+  assert.Equal(t, ml.Port, 8080)
+  assert.Equal(t, ml.Name, "api-gateway")
+  assert.Equal(t, ml.Count, 3)
+  assert.NilError(t, Validate(&MockLimits{}))
+  _, err = runArgs[MockLimits]()
+  assert.NilError(t, err)
+
+  // (bad) Values outside of their limits
+  for _, tc := range []struct {
+    args []string
+    msg  string
+  }{
+    {[]string{"--port", "0"}, "invalid value ['0'] for flag [port]: must be at least 1"},
+    {[]string{"--port", "65536"}, "invalid value ['65536'] for flag [port]: must be at most 65535"},
+    {[]string{"--name", "ab"}, "invalid value ['ab'] for flag [name]: length must be at least 3"},
+    {[]string{"--name", "API"}, "invalid value ['API'] for flag [name]: must match pattern ['^[a-z-]+$']"},
+    {[]string{"--tag", "a", "--tag", "b", "--tag", "c"}, "invalid value ['a', 'b', 'c'] for flag [tag]: length must be at most 2"},
+    {[]string{"--tag", "a", "--tag", "B"}, "invalid value ['B'] for flag [tag]: must match pattern ['^[a-z]+$']"},
+    {[]string{"--size", "1", "--size", "11"}, "invalid value ['11'] for flag [size]: must be at most 10"},
+    {[]string{"0"}, "invalid value ['0'] for arg [count]: must be at least 1"},
+  } {
+    _, err := runArgs[MockLimits](tc.args...)
+    assert.Error(t, err, tc.msg)
+    var usage *UsageError
+    assert.Check(t, errors.As(err, &usage))
+  }
+
+  // (bad) Values from the environment are checked all the same
+  t.Setenv("BASICLI_TEST_PORT", "70000")
+  _, err = runArgs[MockLimits]()
+  var constraint *ConstraintError
+  assert.Assert(t, errors.As(err, &constraint))
+  assert.DeepEqual(t, *constraint, ConstraintError{
+    Kind: "flag", Name: "port", Directive: "max", Limit: "65535", Values: []string{"70000"},
+  })
+
+  // (bad) Limits which don't apply, and defaults outside of them
+  assert.Error(t, Validate(&MockLimitsBad{}), `found 4 problem(s) in command tree:
+  - ['app'] flag ['--port'] has default value ['0']: must be at least 1
+  - ['app'] flag ['--ratio'] has directive ['max=0.5'], which doesn't apply to type ['int']
+  - ['app'] flag ['--debug'] has directive ['minlen=1'], which doesn't apply to type ['bool']
+  - ['app'] arg ['<name>'] has directive ['max=3'], which doesn't apply to type ['string']`)
+}
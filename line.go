@@ -0,0 +1,91 @@
+package basicli
+
+import (
+  "fmt"
+  "reflect"
+  "strings"
+)
+
+// line is a command line parsed against the command tree.
+type line struct {
+  path  []frame               // Commands addressed, from the root
+  args  []string              // Positional args following the subcommand path
+  flags []map[string][]string // Flag values by flag name, for each frame of path
+
+  // Value flag ending the line without its value, if any, as when completing
+  // the value
+  pending *flagDef
+}
+
+// parseLine walks `inputs` (the command line following the executable) from
+// `root`, whose struct value is `rv`.
+//
+// Leading words naming subcommands extend the path, until the first which
+// doesn't, from which point all words are positional args. Flags may appear
+// anywhere, and are bound to the struct of the command which declares them: at
+// any point, the flags accepted are those of the command reached so far along
+// with the persistent flags of its ancestors.
+//
+// Flags which take a value consume the following word. Boolean flags only do so
+// when it's `true` or `false`, so that `app --verbose deploy` addresses the
+// `deploy` subcommand.
+//
+// On error, the line parsed so far is returned, which is what completion
+// resolves a partial command line to.
+func parseLine(cfg *config, root *command, rv reflect.Value, inputs []string) (*line, error) {
+  l := &line{path: []frame{{root, rv}}, flags: []map[string][]string{{}}}
+  for i := 0; i < len(inputs); i++ {
+    input := inputs[i]
+
+    // Subcommands and positional args
+    if len(input) < 2 || input[0] != '-' {
+      if len(l.args) == 0 {
+        path, ok, err := descend(cfg, l.path, input)
+        if err != nil {
+          return l, err
+        }
+        if ok {
+          l.path = path
+          l.flags = append(l.flags, map[string][]string{})
+          continue
+        }
+      }
+      l.args = append(l.args, input)
+      continue
+    }
+
+    // Flags
+    name := strings.TrimLeft(input, "-")
+    cmd := leaf(l.path).cmd
+    flag, err := cmd.findFlag(cfg, name)
+    if err != nil {
+      return l, err
+    }
+    if flag == nil {
+      return l, unknownFlag(cmd, name)
+    }
+
+    value := "true"
+    switch {
+    case flag.takesValue():
+      if i+1 == len(inputs) {
+        l.pending = flag
+        return l, usageError(fmt.Errorf("flag [%s] requires a value", flag.Name))
+      }
+      i++
+      value = inputs[i]
+
+    case i+1 < len(inputs) && isBool(inputs[i+1]):
+      i++
+      value = inputs[i]
+    }
+    flags := l.flags[owner(l.path, flag)]
+    flags[flag.Name] = append(flags[flag.Name], value)
+  }
+  return l, nil
+}
+
+// isBool reports whether `v` is an explicit boolean flag value.
+func isBool(v string) bool {
+  return strings.EqualFold(v, "true") || strings.EqualFold(v, "false")
+}
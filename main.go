@@ -0,0 +1,66 @@
+package basicli
+
+import (
+  "errors"
+  "fmt"
+  "os"
+  "path/filepath"
+  "reflect"
+)
+
+// Main runs the command tree described by `v` (see `Run`), prints any error to
+// stderr and exits the process.
+//
+// The exit code is 0 on success, 2 for usage errors (see `UsageError`) and 1
+// for errors returned by commands, unless the error (or any error it wraps)
+// implements `ExitCode() int`, in which case that code is used. Usage errors
+// are followed by the synopsis of the command addressed. The stack of a
+// recovered panic (see `PanicError`) is printed only when the `BASICLI_DEBUG`
+// environment variable is set.
+func Main[P *T, T any](v P, opts ...Option) {
+  err := Run(v, opts...)
+  if err != nil {
+    cfg := newConfig(opts)
+    fmt.Fprintf(cfg.io.Err, "error: %s\n", err)
+
+    var usage *UsageError
+    if errors.As(err, &usage) {
+      if cmd := addressed(cfg, reflect.TypeOf(v)); cmd != nil {
+        fmt.Fprintf(cfg.io.Err, "usage: %s\n", usageLine(cmd))
+      }
+    }
+
+    var panicked *PanicError
+    if errors.As(err, &panicked) {
+      if len(os.Getenv(envDebug)) > 0 {
+        fmt.Fprintf(cfg.io.Err, "\n%s", panicked.Stack)
+      } else {
+        fmt.Fprintf(cfg.io.Err, "(set %s=1 to print the stack trace)\n", envDebug)
+      }
+    }
+  }
+  exit(exitCode(err))
+}
+
+// exitCode maps `err` to a process exit code.
+func exitCode(err error) int {
+  if err == nil {
+    return 0
+  }
+  var coder interface{ ExitCode() int }
+  if errors.As(err, &coder) {
+    return coder.ExitCode()
+  }
+  return 1
+}
+
+// addressed resolves as much of the subcommand path in `os.Args` against the
+// command tree of struct type `rt` as possible, or nil when there is no tree.
+func addressed(cfg *config, rt reflect.Type) *command {
+  root, err := newCommand(cfg, rt, filepath.Base(os.Args[0]))
+  if err != nil {
+    return nil
+  }
+  l, _ := parseLine(cfg, root, reflect.New(root.Type).Elem(), os.Args[1:])
+  return leaf(l.path).cmd
+}
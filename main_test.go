@@ -0,0 +1,109 @@
+package basicli
+
+import (
+  "errors"
+  "fmt"
+  "os"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockMain struct {
+  Port   int `basicli:"port"`
+  Deploy MockMainDeploy
+}
+
+func (MockMain) Exec() error { return nil }
+
+func (MockMain) Crash() error { panic("boom") }
+
+type MockMainDeploy struct {
+  Region string `basicli:"region,required=true"`
+}
+
+func (MockMainDeploy) Exec() error { return fmt.Errorf("deploy failed") }
+
+func (MockMainDeploy) Custom() error { return exitCoder{} }
+
+type exitCoder struct{}
+
+func (exitCoder) Error() string { return "custom failure" }
+func (exitCoder) ExitCode() int { return 42 }
+
+func TestMainExitCodes(t *testing.T) {
+  var code int
+  exit = func(c int) { code = c }
+  defer func() { exit = os.Exit }()
+
+  var stderr strings.Builder
+  run := func(args ...string) (int, string) {
+    stderr.Reset()
+    os.Args = append([]string{"app"}, args...)
+    Main(&MockMain{}, WithIO(&IO{Err: &stderr}))
+    return code, stderr.String()
+  }
+
+  // Success
+  code, out := run()
+  assert.Equal(t, code, 0)
+  assert.Equal(t, out, "")
+
+  // Usage errors: unknown flags, missing required flags and bad conversions
+  code, out = run("--prot", "80")
+  assert.Equal(t, code, 2)
+  assert.Equal(t, out, "error: unknown flag '--prot', did you mean '--port'?\nusage: app [command] [flags]\n")
+  code, out = run("deploy")
+  assert.Equal(t, code, 2)
+  assert.Equal(t, out, "error: flag [region] is required but was not provided\nusage: app deploy [command] [flags]\n")
+  code, out = run("--port", "eighty")
+  assert.Equal(t, code, 2)
+  assert.Check(t, strings.HasPrefix(out, "error: invalid value ['eighty'] for flag [port]: "), out)
+
+  // Command errors
+  code, out = run("deploy", "--region", "us")
+  assert.Equal(t, code, 1)
+  assert.Equal(t, out, "error: deploy failed\n")
+
+  // Custom exit codes
+  code, out = run("deploy", "custom", "--region", "us")
+  assert.Equal(t, code, 42)
+  assert.Equal(t, out, "error: custom failure\n")
+}
+
+func TestMainPanic(t *testing.T) {
+  var code int
+  exit = func(c int) { code = c }
+  defer func() { exit = os.Exit }()
+
+  var stderr strings.Builder
+  os.Args = []string{"app", "crash"}
+
+  // The stack is withheld by default
+  t.Setenv(envDebug, "")
+  Main(&MockMain{}, WithIO(&IO{Err: &stderr}))
+  assert.Equal(t, code, 1)
+  assert.Equal(t, stderr.String(), "error: panic in ['app crash']: boom\n(set BASICLI_DEBUG=1 to print the stack trace)\n")
+
+  // And printed when debugging
+  stderr.Reset()
+  t.Setenv(envDebug, "1")
+  Main(&MockMain{}, WithIO(&IO{Err: &stderr}))
+  assert.Equal(t, code, 1)
+  assert.Check(t, strings.HasPrefix(stderr.String(), "error: panic in ['app crash']: boom\n\ngoroutine "), stderr.String())
+  assert.Check(t, strings.Contains(stderr.String(), "MockMain.Crash"), stderr.String())
+}
+
+func TestRunPanic(t *testing.T) {
+  // Panics raised around the command are recovered too
+  os.Args = []string{"app", "deploy", "--region", "us"}
+  err := Run(&MockMain{}, WithMiddleware(func(next Handler) Handler {
+    panic(fmt.Errorf("middleware failed"))
+  }))
+  var panicked *PanicError
+  assert.Assert(t, errors.As(err, &panicked))
+  assert.DeepEqual(t, panicked.Command, []string{"app", "deploy"})
+  assert.Error(t, errors.Unwrap(err), "middleware failed")
+  assert.Check(t, len(panicked.Stack) > 0)
+}
@@ -0,0 +1,71 @@
+package basicli
+
+import (
+  "context"
+  "fmt"
+)
+
+// Invocation describes a dispatched command, as seen by a `Handler`.
+type Invocation struct {
+  Path    []string // Names of the commands addressed, from the root
+  Command any      // The struct of the command, as bound by `Unmarshal`
+  Args    []string // Positional args left over after the subcommand path
+  IO      *IO
+}
+
+// Handler runs a dispatched command.
+type Handler func(ctx context.Context, inv *Invocation) error
+
+// Middleware wraps a `Handler`, for cross-cutting concerns such as timing,
+// audit logging or feature gating. It may act before and after calling `next`,
+// or decline to call it at all.
+//
+// Middleware is registered for the whole tree with `WithMiddleware`, or for a
+// subtree by declaring a method on its struct:
+//
+//  func (Cloud) Middleware() []basicli.Middleware
+//
+// Middleware registered with `WithMiddleware` runs outermost, followed by that
+// of each struct along the subcommand path from the root down. The innermost
+// handler runs the command itself, within its lifecycle hooks.
+type Middleware func(next Handler) Handler
+
+// chain wraps `h` in the middleware registered with `cfg` and on the structs of
+// `path`, such that the first middleware runs outermost.
+func chain(cfg *config, path []frame, h Handler) (Handler, error) {
+  mws := cfg.middleware
+  for _, f := range path {
+    method := methodByName(f.rv, methodMiddleware)
+    if !method.IsValid() {
+      continue
+    }
+    fn, ok := method.Interface().(func() []Middleware)
+    if !ok {
+      return nil, fmt.Errorf(
+        "found unsupported ['%s'] method signature ['%s'] on type ['%s']",
+        methodMiddleware, method.Type(), f.rv.Type().Name(),
+      )
+    }
+    mws = append(mws[:len(mws):len(mws)], fn()...)
+  }
+
+  for i := len(mws) - 1; i >= 0; i-- {
+    h = mws[i](h)
+  }
+  return h, nil
+}
+
+// invocation describes the command at the end of `path` for handlers.
+func invocation(cfg *config, path []frame, args []string) *Invocation {
+  rv := leaf(path).rv
+  command := rv.Interface()
+  if rv.CanAddr() {
+    command = rv.Addr().Interface()
+  }
+  return &Invocation{
+    Path:    leaf(path).cmd.Path,
+    Command: command,
+    Args:    args,
+    IO:      cfg.io,
+  }
+}
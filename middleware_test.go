@@ -0,0 +1,111 @@
+package basicli
+
+import (
+  "context"
+  "fmt"
+  "os"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+var mockMiddlewareTrace []string
+
+func mockTrace(name string) Middleware {
+  return func(next Handler) Handler {
+    return func(ctx context.Context, inv *Invocation) error {
+      mockMiddlewareTrace = append(mockMiddlewareTrace, fmt.Sprintf("%s > %s", name, strings.Join(inv.Path, " ")))
+      err := next(ctx, inv)
+      mockMiddlewareTrace = append(mockMiddlewareTrace, fmt.Sprintf("%s < %v", name, err))
+      return err
+    }
+  }
+}
+
+type MockMiddleware struct {
+  Cloud MockMiddlewareCloud
+}
+
+func (MockMiddleware) Before(ctx context.Context) error {
+  mockMiddlewareTrace = append(mockMiddlewareTrace, "before root")
+  return nil
+}
+
+func (MockMiddleware) Version() error {
+  mockMiddlewareTrace = append(mockMiddlewareTrace, "version")
+  return nil
+}
+
+type MockMiddlewareCloud struct {
+  Deploy MockMiddlewareDeploy
+}
+
+func (MockMiddlewareCloud) Middleware() []Middleware {
+  return []Middleware{
+    mockTrace("cloud"),
+    // Gate the subtree behind a flag of the bound command
+    func(next Handler) Handler {
+      return func(ctx context.Context, inv *Invocation) error {
+        if deploy, ok := inv.Command.(*MockMiddlewareDeploy); ok && deploy.Canary {
+          return fmt.Errorf("canary deploys are disabled")
+        }
+        return next(ctx, inv)
+      }
+    },
+  }
+}
+
+type MockMiddlewareDeploy struct {
+  Canary bool `basicli:"canary"`
+}
+
+func (MockMiddlewareDeploy) Exec(ctx context.Context, args []string) error {
+  mockMiddlewareTrace = append(mockMiddlewareTrace, fmt.Sprintf("deploy %v", args))
+  return nil
+}
+
+func TestMiddleware(t *testing.T) {
+  run := func(args ...string) error {
+    mockMiddlewareTrace = nil
+    os.Args = append([]string{"app"}, args...)
+    return Run(&MockMiddleware{}, WithMiddleware(mockTrace("global")))
+  }
+
+  // Global middleware runs outermost, then each subtree's from the root down,
+  // wrapping the command and its hooks
+  err := run("cloud", "deploy")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, mockMiddlewareTrace, []string{
+    "global > app cloud deploy",
+    "cloud > app cloud deploy",
+    "before root",
+    "deploy []",
+    "cloud < <nil>",
+    "global < <nil>",
+  })
+
+  // Middleware sees the bound command, and may decline to call the next handler
+  err = run("cloud", "deploy", "--canary")
+  assert.Error(t, err, "canary deploys are disabled")
+  assert.DeepEqual(t, mockMiddlewareTrace, []string{
+    "global > app cloud deploy",
+    "cloud > app cloud deploy",
+    "cloud < canary deploys are disabled",
+    "global < canary deploys are disabled",
+  })
+
+  // Subtree middleware doesn't apply outside of its subtree
+  err = run("version")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, mockMiddlewareTrace, []string{
+    "global > app version",
+    "before root",
+    "version",
+    "global < <nil>",
+  })
+
+  // Middleware isn't a subcommand
+  err = run("cloud", "middleware")
+  assert.Error(t, err, "unknown command 'middleware'")
+}
@@ -0,0 +1,47 @@
+package basicli
+
+import (
+  "strings"
+  "unicode"
+)
+
+// Naming derives the name of a subcommand, flag or positional arg from the Go
+// name of its field (or method), for those whose tag doesn't name them. It's
+// configured with `WithNaming`, and defaults to `KebabCase`.
+//
+// Whatever the strategy, the Go name itself is always accepted as well, so
+// `DryRun` may be provided as either `--dry-run` or `--DryRun`. Flags of option
+// groups are qualified by the group's field, as in `--DB.Host`.
+//
+// Names are matched according to a fixed case policy: subcommands match
+// case-insensitively (`app Deploy` runs `deploy`), while flags match
+// case-sensitively, so that `-v` and `-V` may be distinct flags. Flags match
+// case-insensitively too with `WithCaseInsensitiveFlags`.
+type Naming func(name string) string
+
+// KebabCase names `DryRun` as `dry-run`, and `TLSKey` as `tls-key`.
+func KebabCase(name string) string {
+  runes := []rune(name)
+  var b strings.Builder
+  for i, r := range runes {
+    if i > 0 && unicode.IsUpper(r) {
+      prev := runes[i-1]
+      acronymEnd := unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+      if unicode.IsLower(prev) || unicode.IsDigit(prev) || acronymEnd {
+        b.WriteByte('-')
+      }
+    }
+    b.WriteRune(unicode.ToLower(r))
+  }
+  return b.String()
+}
+
+// LowerCase names `DryRun` as `dryrun`.
+func LowerCase(name string) string {
+  return strings.ToLower(name)
+}
+
+// GoName names `DryRun` as `DryRun`.
+func GoName(name string) string {
+  return name
+}
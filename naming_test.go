@@ -0,0 +1,70 @@
+package basicli
+
+import (
+  "os"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockNaming struct {
+  DryRun    bool
+  TLSKey    string
+  Verbose   bool `basicli:"verbose,v"`
+  Version   bool `basicli:"V"`
+  DeployAll MockNamingDeploy
+}
+
+type MockNamingDeploy struct {
+  MaxRetries int
+}
+
+func (MockNamingDeploy) Exec() error { return nil }
+
+func (MockNamingDeploy) RollBack() error { return nil }
+
+func TestKebabCase(t *testing.T) {
+  for name, want := range map[string]string{
+    "DryRun":     "dry-run",
+    "TLSKey":     "tls-key",
+    "HTTPProxy":  "http-proxy",
+    "ID":         "id",
+    "Port2":      "port2",
+    "V2Endpoint": "v2-endpoint",
+    "x":          "x",
+  } {
+    assert.Check(t, KebabCase(name) == want, "%s: %s", name, KebabCase(name))
+  }
+}
+
+func TestNaming(t *testing.T) {
+  // (good) Untagged names are kebab-case by default, and Go names still match
+  mn, err := runArgs[MockNaming]("--TLSKey", "k", "deploy-all", "--max-retries", "3")
+  assert.NilError(t, err)
+  assert.Equal(t, mn.TLSKey, "k")
+  assert.Equal(t, mn.DeployAll.MaxRetries, 3)
+  mn, err = runArgs[MockNaming]("--tls-key", "k", "DeployAll", "roll-back", "--MaxRetries", "1")
+  assert.NilError(t, err)
+  assert.Equal(t, mn.DeployAll.MaxRetries, 1)
+
+  // (good) Other strategies
+  mn = &MockNaming{}
+  os.Args = []string{"app", "--tlskey", "k", "deployall", "--maxretries", "2"}
+  assert.NilError(t, Run(mn, WithNaming(LowerCase)))
+  assert.Equal(t, mn.DeployAll.MaxRetries, 2)
+  os.Args = []string{"app", "--tls-key", "k"}
+  assert.Error(t, Run(&MockNaming{}, WithNaming(GoName)), "unknown flag '--tls-key'")
+
+  // (bad) Flags are case-sensitive by default, subcommands aren't
+  _, err = runArgs[MockNaming]("--Dry-Run", "DEPLOY-ALL")
+  assert.Error(t, err, "unknown flag '--Dry-Run', did you mean '--dry-run'?")
+
+  // (good) Unless opted into, where exact matches still take precedence
+  mn = &MockNaming{}
+  os.Args = []string{"app", "--Dry-Run", "-V", "DEPLOY-ALL", "--MAX-RETRIES", "4"}
+  assert.NilError(t, Run(mn, WithCaseInsensitiveFlags()))
+  assert.Check(t, mn.DryRun)
+  assert.Check(t, mn.Version)
+  assert.Check(t, !mn.Verbose)
+  assert.Equal(t, mn.DeployAll.MaxRetries, 4)
+}
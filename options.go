@@ -0,0 +1,92 @@
+package basicli
+
+import (
+  "context"
+  "time"
+)
+
+// Option configures the behaviour of `Run`, `Unmarshal` and `Dispatch`.
+// Options shaping the command tree, such as `WithNaming`, must also be passed
+// to `Validate`, `Completion` and `GenerateDocs`.
+type Option func(*config)
+
+type config struct {
+  prefixMatching bool
+  ctx            context.Context
+  io             *IO
+  gracePeriod    time.Duration
+  middleware     []Middleware
+  naming         Naming
+  foldFlags      bool
+}
+
+func newConfig(opts []Option) *config {
+  cfg := &config{ctx: context.Background(), io: stdio(), naming: KebabCase}
+  for _, opt := range opts {
+    opt(cfg)
+  }
+  return cfg
+}
+
+// WithPrefixMatching allows subcommands and flags to be referred to by any
+// prefix of their names (or aliases) which is unique at that level of the
+// command tree, so `app dep --reg us` resolves to `app deploy --region us`.
+//
+// Exact matches always take precedence. A prefix shared by more than one
+// subcommand or flag produces an `*AmbiguousError`.
+func WithPrefixMatching() Option {
+  return func(cfg *config) {
+    cfg.prefixMatching = true
+  }
+}
+
+// WithContext provides the context passed to command methods which accept one.
+// It defaults to `context.Background()`.
+func WithContext(ctx context.Context) Option {
+  return func(cfg *config) {
+    cfg.ctx = ctx
+  }
+}
+
+// WithGracePeriod bounds how long `Run` waits for a command to return once its
+// context has been cancelled by SIGINT or SIGTERM, before terminating the
+// process with exit code 130. By default it waits until a second signal.
+func WithGracePeriod(d time.Duration) Option {
+  return func(cfg *config) {
+    cfg.gracePeriod = d
+  }
+}
+
+// WithIO provides the streams passed to command methods which accept an `*IO`,
+// and written to by built-in commands. It defaults to the process' standard
+// streams.
+func WithIO(io *IO) Option {
+  return func(cfg *config) {
+    cfg.io = io
+  }
+}
+
+// WithMiddleware registers middleware around every dispatched command. The
+// first middleware provided runs outermost.
+func WithMiddleware(mws ...Middleware) Option {
+  return func(cfg *config) {
+    cfg.middleware = append(cfg.middleware, mws...)
+  }
+}
+
+// WithNaming sets the strategy deriving the names of subcommands, flags and
+// positional args which aren't named by their tag. It defaults to `KebabCase`.
+func WithNaming(naming Naming) Option {
+  return func(cfg *config) {
+    cfg.naming = naming
+  }
+}
+
+// WithCaseInsensitiveFlags matches flag names and aliases regardless of case,
+// as subcommands are, when no flag matches exactly. See `Naming` for the case
+// policy.
+func WithCaseInsensitiveFlags() Option {
+  return func(cfg *config) {
+    cfg.foldFlags = true
+  }
+}
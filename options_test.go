@@ -0,0 +1,65 @@
+package basicli
+
+import (
+  "errors"
+  "os"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockPrefix struct {
+  Deploy   MockPrefixDeploy   `basicli:"deploy"`
+  Describe MockPrefixDescribe `basicli:"describe,desc,show"`
+}
+
+type MockPrefixDescribe struct{}
+
+func (MockPrefixDescribe) Exec() error { return nil }
+
+type MockPrefixDeploy struct {
+  Region  string `basicli:"region,r"`
+  Replica int    `basicli:"replicas"`
+  Verbose bool   `basicli:"verbose"`
+}
+
+func (MockPrefixDeploy) Exec() error { return nil }
+
+func TestWithPrefixMatching(t *testing.T) {
+  var mp MockPrefix
+
+  // (good) Unique prefixes of subcommands and flags
+  os.Args = []string{"", "dep", "--reg", "us", "--verb"}
+  assert.NilError(t, Run(&mp, WithPrefixMatching()))
+  assert.Equal(t, mp.Deploy.Region, "us")
+  assert.Check(t, mp.Deploy.Verbose)
+
+  // (good) Exact matches win over prefixes
+  os.Args = []string{"", "deploy", "-r", "eu"}
+  assert.NilError(t, Run(&mp, WithPrefixMatching()))
+  assert.Equal(t, mp.Deploy.Region, "eu")
+
+  // (bad) Prefix matching is opt-in
+  os.Args = []string{"", "dep"}
+  assert.Error(t, Run(&mp), "unknown command 'dep'")
+
+  // (bad) Ambiguous subcommand prefix
+  os.Args = []string{"", "de"}
+  err := Run(&mp, WithPrefixMatching())
+  assert.Error(t, err, "ambiguous command 'de', could be 'deploy', 'describe' or 'desc'")
+  var ambiguous *AmbiguousError
+  assert.Assert(t, errors.As(err, &ambiguous))
+  assert.DeepEqual(t, ambiguous.Candidates, []string{"deploy", "describe", "desc"})
+
+  // (bad) Ambiguous flag prefix
+  os.Args = []string{"", "deploy", "--re", "us"}
+  assert.Error(t, Run(&mp, WithPrefixMatching()),
+    "ambiguous flag '--re', could be '--region' or '--replicas'")
+
+  // (good) Aliases take part, and matching several names of the same
+  // subcommand isn't ambiguous
+  os.Args = []string{"", "sh"}
+  assert.NilError(t, Run(&mp, WithPrefixMatching()))
+  os.Args = []string{"", "des"}
+  assert.NilError(t, Run(&mp, WithPrefixMatching()))
+}
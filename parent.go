@@ -0,0 +1,33 @@
+package basicli
+
+import "reflect"
+
+// injectParents populates the parent fields of `cmd` on its struct `rv` with
+// pointers to the nearest struct of `ancestors` (ordered from the root) of
+// matching type. Parent fields are pointers to an ancestor command's struct,
+// tagged `-,parent`:
+//
+//  type Deploy struct {
+//    Root  *Root  `basicli:"-,parent"`
+//    Cloud *Cloud `basicli:"-,parent"`
+//  }
+//
+// This gives commands access to the flags of the commands above them, such as a
+// root `--profile`. Parent fields without a matching ancestor are left as is.
+func injectParents(cmd *command, rv reflect.Value, ancestors []frame) {
+  if !rv.IsValid() {
+    return
+  }
+  for _, sf := range cmd.Parents {
+    if sf.Type.Kind() != reflect.Pointer {
+      continue
+    }
+    for j := len(ancestors) - 1; j >= 0; j-- {
+      parent := ancestors[j].rv
+      if parent.IsValid() && parent.CanAddr() && parent.Type() == sf.Type.Elem() {
+        fieldByIndex(rv, sf.Index).Set(parent.Addr())
+        break
+      }
+    }
+  }
+}
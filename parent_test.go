@@ -0,0 +1,54 @@
+package basicli
+
+import (
+  "os"
+  "reflect"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+var mockParentSeen string
+
+type MockParent struct {
+  Profile string `basicli:"profile"`
+  Cloud   MockParentCloud
+}
+
+type MockParentCloud struct {
+  Region string `basicli:"region"`
+  Deploy MockParentDeploy
+}
+
+type MockParentDeploy struct {
+  Root   *MockParent      `basicli:"-,parent"`
+  Cloud  *MockParentCloud `basicli:"-,parent"`
+  Ignore string           `basicli:"-"`
+  Canary bool             `basicli:"canary"`
+}
+
+func (self MockParentDeploy) Exec() error {
+  mockParentSeen = self.Root.Profile + " " + self.Cloud.Region
+  return nil
+}
+
+func TestParent(t *testing.T) {
+  // Ancestors are injected into parent fields during the tree walk
+  os.Args = []string{"app", "--profile", "prod", "cloud", "--region", "us", "deploy", "--canary"}
+  v := &MockParent{}
+  err := Run(v)
+  assert.NilError(t, err)
+  assert.Equal(t, mockParentSeen, "prod us")
+  assert.Equal(t, v.Cloud.Deploy.Root, v)
+  assert.Equal(t, v.Cloud.Deploy.Cloud, &v.Cloud)
+
+  // Skipped fields are neither flags nor subcommands
+  root, err := newCommand(newConfig(nil), reflect.TypeFor[MockParent](), "app")
+  assert.NilError(t, err)
+  deploy := root.lookup("cloud").lookup("deploy")
+  assert.Equal(t, len(deploy.Flags), 1)
+  assert.Equal(t, len(deploy.Commands), 0)
+  os.Args = []string{"app", "cloud", "deploy", "--Ignore", "x"}
+  err = Run(&MockParent{})
+  assert.Error(t, err, "unknown flag '--Ignore'")
+}
@@ -0,0 +1,116 @@
+package basicli
+
+import (
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockPersistent struct {
+  Verbose bool   `basicli:"verbose,v,persistent=true"`
+  Profile string `basicli:"profile,persistent,env=BASICLI_TEST_PROFILE"`
+  Color   bool   `basicli:"color"`
+  Deploy  MockPersistentDeploy
+}
+
+func (MockPersistent) Exec() error { return nil }
+
+type MockPersistentDeploy struct {
+  Root   *MockPersistent `basicli:"-,parent"`
+  Canary bool            `basicli:"canary"`
+  Target string          `basicli:"target,pos=0,default=all"`
+}
+
+func (MockPersistentDeploy) Exec() error { return nil }
+
+func (MockPersistentDeploy) Status() error { return nil }
+
+func (MockPersistent) CompleteProfile(prefix string) []string {
+  return []string{"dev", "prod"}
+}
+
+type MockPersistentConflict struct {
+  Verbose bool `basicli:"verbose,persistent=true"`
+  Broken  MockPersistentBroken
+}
+
+type MockPersistentBroken struct {
+  Verbose string `basicli:"verbose"`
+}
+
+func (MockPersistentBroken) Exec() error { return nil }
+
+func TestPersistentFlags(t *testing.T) {
+  // (good) Flags are bound to the struct declaring them, wherever they appear
+  mp, err := runArgs[MockPersistent]("--verbose", "deploy", "--profile", "prod", "--canary")
+  assert.NilError(t, err)
+  assert.Check(t, mp.Verbose)
+  assert.Equal(t, mp.Profile, "prod")
+  assert.Check(t, mp.Deploy.Canary)
+  assert.Equal(t, mp.Deploy.Root, mp)
+
+  // (good) Including after positional args, and on method leaf subcommands
+  mp, err = runArgs[MockPersistent]("deploy", "web", "-v")
+  assert.NilError(t, err)
+  assert.Check(t, mp.Verbose)
+  assert.Equal(t, mp.Deploy.Target, "web")
+  mp, err = runArgs[MockPersistent]("deploy", "status", "--verbose")
+  assert.NilError(t, err)
+  assert.Check(t, mp.Verbose)
+
+  // (good) Boolean flags only consume an explicit value
+  mp, err = runArgs[MockPersistent]("--color", "deploy")
+  assert.NilError(t, err)
+  assert.Check(t, mp.Color)
+  assert.Equal(t, mp.Deploy.Target, "all")
+  mp, err = runArgs[MockPersistent]("--color", "true", "deploy", "-v", "false")
+  assert.NilError(t, err)
+  assert.Check(t, mp.Color)
+  assert.Check(t, !mp.Verbose)
+
+  // (good) The environment applies to persistent flags of ancestors
+  t.Setenv("BASICLI_TEST_PROFILE", "staging")
+  mp, err = runArgs[MockPersistent]("deploy")
+  assert.NilError(t, err)
+  assert.Equal(t, mp.Profile, "staging")
+
+  // (bad) A bare `persistent` is a directive rather than an alias
+  _, err = runArgs[MockPersistent]("--persistent", "deploy")
+  assert.Error(t, err, "unknown flag '--persistent'")
+
+  // (bad) Flags which aren't persistent are only accepted ahead of subcommands
+  _, err = runArgs[MockPersistent]("deploy", "--color")
+  assert.Error(t, err, "unknown flag '--color'")
+
+  // (bad) Value flags require a value
+  _, err = runArgs[MockPersistent]("deploy", "--profile")
+  assert.Error(t, err, "flag [profile] requires a value")
+
+  // (bad) Flags conflicting with an inherited persistent flag
+  os.Args = []string{"app", "broken"}
+  err = Dispatch(&MockPersistentConflict{})
+  assert.Error(t, err, "flag ['--verbose'] of ['app broken'] conflicts with persistent flag ['--verbose'] of ['app']")
+}
+
+func TestPersistentFlagsComplete(t *testing.T) {
+  var mp MockPersistent
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&mp), "app")
+  assert.NilError(t, err)
+  rv := Concrete(reflect.ValueOf(&mp))
+  complete := func(words ...string) []string {
+    return complete(newConfig(nil), root, rv, words)
+  }
+
+  // Inherited flags are offered, and their values completed by their own struct
+  assert.DeepEqual(t, complete("deploy", "--"), []string{"--canary", "--verbose", "--profile"})
+  assert.DeepEqual(t, complete("deploy", "--profile", ""), []string{"dev", "prod"})
+
+  // And documented
+  var b strings.Builder
+  writeDoc(&b, root.lookup("deploy"))
+  assert.Check(t, strings.Contains(b.String(), "\n## Inherited flags\n\n"), b.String())
+  assert.Check(t, strings.Contains(b.String(), "app deploy [command] [flags] [<target>]"), b.String())
+}
@@ -0,0 +1,48 @@
+package basicli
+
+import "reflect"
+
+// bindArgs assigns the positional `args` left over after the subcommand path to
+// the positional arg fields of `cmd` on struct `rv`, after checking their count
+// against the arity of `cmd`, then checks the values bound against their
+// limits. Commands without positional arg fields leave `args` to the method
+// they run.
+func bindArgs(cmd *command, rv reflect.Value, args []string) error {
+  // Without positional arg fields, any args are left to the method run
+  if len(cmd.Args) == 0 {
+    return nil
+  }
+  lo, hi := cmd.arity()
+  if len(args) < lo || hi >= 0 && len(args) > hi {
+    return usageError(&ArityError{Command: cmd.Path, Min: lo, Max: hi, Got: len(args)})
+  }
+
+  fixed := cmd.fixedArgs()
+  for _, arg := range cmd.Args {
+    var vs []string
+    switch {
+    case arg.Rest:
+      if len(args) > fixed {
+        vs = args[fixed:]
+      }
+    case arg.Pos < len(args):
+      vs = args[arg.Pos : arg.Pos+1]
+    case arg.HasDefault:
+      vs = []string{arg.Default}
+    }
+    if err := choose(arg.Choices, vs); err != nil {
+      return invalidValue("arg", arg.Name, []string{err.Value}, err)
+    }
+    field := fieldByIndex(rv, arg.Field.Index)
+    if err := fieldSet(field, vs); err != nil {
+      return invalidValue("arg", arg.Name, vs, err)
+    }
+    if len(vs) > 0 {
+      if err := constrain("arg", arg.Name, arg.Limits, field); err != nil {
+        return err
+      }
+    }
+  }
+
+  return nil
+}
@@ -0,0 +1,99 @@
+package basicli
+
+import (
+  "errors"
+  "os"
+  "reflect"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockPositional struct {
+  Copy MockPositionalCopy `basicli:"copy,cp"`
+  Tail struct {
+    Lines int    `basicli:"pos=1,default=10"`
+    File  string `basicli:"file,pos=0"`
+  }
+}
+
+type MockPositionalCopy struct {
+  Force bool     `basicli:"force,f"`
+  Src   string   `basicli:"src,pos=0"`
+  Dst   string   `basicli:"dst,pos=1"`
+  More  []string `basicli:"more,pos=rest"`
+}
+
+func (MockPositionalCopy) Exec() error { return nil }
+
+func (MockPositionalCopy) CompleteSrc(prefix string) []string {
+  return []string{"a.txt", "b.txt"}
+}
+
+type MockPositionalDefaults struct {
+  Src string `basicli:"src,pos=0,default=a"`
+  Dst string `basicli:"dst,pos=1"`
+}
+
+func (MockPositionalDefaults) Exec() error { return nil }
+
+func TestPositional(t *testing.T) {
+  // (good) Fixed positional args, flags may come before or after
+  var mp MockPositional
+  os.Args = []string{"", "copy", "src.txt", "dst.txt", "-f"}
+  assert.NilError(t, Run(&mp))
+  assert.Equal(t, mp.Copy.Src, "src.txt")
+  assert.Equal(t, mp.Copy.Dst, "dst.txt")
+  assert.Check(t, mp.Copy.Force)
+  assert.Check(t, len(mp.Copy.More) == 0)
+
+  // (good) Rest args are collected into a slice
+  mp = MockPositional{}
+  os.Args = []string{"", "cp", "a", "b", "c", "d"}
+  assert.NilError(t, Run(&mp))
+  assert.DeepEqual(t, mp.Copy.More, []string{"c", "d"})
+
+  // (good) Optional args with defaults, with type conversion
+  mp = MockPositional{}
+  os.Args = []string{"", "tail", "x.log"}
+  assert.NilError(t, Unmarshal(&mp))
+  assert.Equal(t, mp.Tail.File, "x.log")
+  assert.Equal(t, mp.Tail.Lines, 10)
+  os.Args = []string{"", "tail", "x.log", "25"}
+  assert.NilError(t, Unmarshal(&mp))
+  assert.Equal(t, mp.Tail.Lines, 25)
+
+  // (bad) Too few args
+  os.Args = []string{"app", "copy", "src.txt"}
+  err := Unmarshal(&mp)
+  assert.Error(t, err, "'app copy' expects at least 2 positional args, found 1")
+  var arity *ArityError
+  assert.Assert(t, errors.As(err, &arity))
+  assert.Equal(t, arity.Min, 2)
+  assert.Equal(t, arity.Max, -1)
+
+  // (bad) Too many args
+  os.Args = []string{"app", "tail", "a", "1", "b"}
+  assert.Error(t, Unmarshal(&mp), "'app tail' expects between 1 and 2 positional args, found 3")
+
+  // (bad) Defaults of args followed by a required arg never apply
+  assert.Error(t, Validate(&MockPositionalDefaults{}), `found 1 problem(s) in command tree:
+  - ['app'] arg ['<src>'] has a default value, but is followed by a required arg`)
+
+  // (bad) Commands without positional args still reject unknown names
+  os.Args = []string{"app", "cpy"}
+  assert.Error(t, Unmarshal(&mp), "unknown command 'cpy', did you mean 'copy' or 'cp'?")
+}
+
+func TestPositionalComplete(t *testing.T) {
+  var mp MockPositional
+  root, err := newCommand(newConfig(nil), reflect.TypeOf(&mp), "app")
+  assert.NilError(t, err)
+  rv := Concrete(reflect.ValueOf(&mp))
+  complete := func(words ...string) []string {
+    return complete(newConfig(nil), root, rv, words)
+  }
+
+  assert.DeepEqual(t, complete("copy", "a"), []string{"a.txt"})
+  assert.Check(t, len(complete("copy", "a.txt", "")) == 0)
+}
@@ -0,0 +1,183 @@
+package basicli
+
+import (
+  "errors"
+  "fmt"
+  "os"
+  "reflect"
+  "slices"
+  "strings"
+)
+
+// Rule constrains which flags of a command may, or must, be provided together.
+// Rules are declared by a method on the struct of the command, and checked once
+// its flags are bound:
+//
+//  func (Upload) Rules() []basicli.Rule {
+//    return []basicli.Rule{
+//      basicli.Exclusive("file", "stdin"),
+//      basicli.Requires("tls-key", "tls-cert"),
+//      basicli.RequiredIf("region", "cloud", "aws"),
+//      basicli.AtLeastOne("all", "name"),
+//    }
+//  }
+//
+// Flags are referred to by name, and must belong to the struct declaring the
+// rule. A flag counts as provided when set on the command line or through its
+// environment variable, but not by its default value.
+//
+// Like required flags, `RequiredIf` and `AtLeastOne` rules only apply when
+// their command is the one addressed, while `Exclusive` and `Requires` rules
+// apply whenever their flags are provided.
+type Rule struct {
+  kind  ruleKind
+  flags []string
+  value string // The value of the flag a `RequiredIf` rule depends on
+}
+
+type ruleKind uint8
+
+const (
+  ruleExclusive ruleKind = iota
+  ruleRequires
+  ruleRequiredIf
+  ruleAtLeastOne
+)
+
+func (self ruleKind) String() string {
+  return [...]string{"Exclusive", "Requires", "RequiredIf", "AtLeastOne"}[self]
+}
+
+// Exclusive declares that at most one of `flags` may be provided.
+func Exclusive(flags ...string) Rule {
+  return Rule{kind: ruleExclusive, flags: flags}
+}
+
+// Requires declares that `flag` may only be provided along with each of
+// `required`.
+func Requires(flag string, required ...string) Rule {
+  return Rule{kind: ruleRequires, flags: append([]string{flag}, required...)}
+}
+
+// RequiredIf declares that `flag` must be provided when flag `other` has value
+// `value`, whether provided or by default.
+func RequiredIf(flag, other, value string) Rule {
+  return Rule{kind: ruleRequiredIf, flags: []string{flag, other}, value: value}
+}
+
+// AtLeastOne declares that one or more of `flags` must be provided.
+func AtLeastOne(flags ...string) Rule {
+  return Rule{kind: ruleAtLeastOne, flags: flags}
+}
+
+// describe states the rule, naming flags with `name` and quoting values with
+// `quote`.
+func (self Rule) describe(name, quote func(string) string) string {
+  names := make([]string, len(self.flags))
+  for i, flag := range self.flags {
+    names[i] = name(flag)
+  }
+  switch self.kind {
+  case ruleExclusive:
+    return fmt.Sprintf("flags %s are mutually exclusive", joinList(names, "and"))
+  case ruleRequires:
+    return fmt.Sprintf("flag %s requires flag %s", names[0], joinList(names[1:], "and"))
+  case ruleRequiredIf:
+    return fmt.Sprintf("flag %s is required when flag %s is %s", names[0], names[1], quote(self.value))
+  }
+  return fmt.Sprintf("at least one of flags %s is required", joinList(names, "or"))
+}
+
+// rules produces the rules declared by struct type `rt`, if any. Rules are
+// static, so are taken from its zero value.
+func rules(rt reflect.Type) []Rule {
+  method := reflect.New(rt).MethodByName(methodRules)
+  if !method.IsValid() {
+    return nil
+  }
+  fn, ok := method.Interface().(func() []Rule)
+  if !ok {
+    // Reported by `Validate`
+    return nil
+  }
+  return fn()
+}
+
+// checkRules checks the rules of `cmd` against the flags bound on its struct
+// `rv`, those of `flags` having been provided on the command line.
+func checkRules(cmd *command, rv reflect.Value, flags map[string][]string, addressed bool) error {
+  provided := func(name string) bool {
+    flag := cmd.ownFlag(name)
+    if flag == nil {
+      return false
+    }
+    if _, ok := flags[flag.Name]; ok {
+      return true
+    }
+    _, ok := os.LookupEnv(flag.Env)
+    return len(flag.Env) > 0 && ok
+  }
+  name := func(flag string) string {
+    return "[" + flag + "]"
+  }
+  quote := func(v string) string {
+    return "['" + v + "']"
+  }
+
+  for _, rule := range cmd.Rules {
+    present := slices.DeleteFunc(slices.Clone(rule.flags), func(flag string) bool {
+      return !provided(flag)
+    })
+
+    switch rule.kind {
+    case ruleExclusive:
+      if len(present) > 1 {
+        return usageError(errors.New(Exclusive(present...).describe(name, quote)))
+      }
+
+    case ruleRequires:
+      if !provided(rule.flags[0]) {
+        continue
+      }
+      for _, required := range rule.flags[1:] {
+        if !provided(required) {
+          return usageError(errors.New(Requires(rule.flags[0], required).describe(name, quote)))
+        }
+      }
+
+    case ruleRequiredIf:
+      other := cmd.ownFlag(rule.flags[1])
+      if !addressed || provided(rule.flags[0]) || other == nil {
+        continue
+      }
+      if slices.Contains(display(Concrete(fieldByIndex(rv, other.Field.Index))), rule.value) {
+        return usageError(errors.New(rule.describe(name, quote)))
+      }
+
+    case ruleAtLeastOne:
+      if addressed && len(present) == 0 {
+        return usageError(errors.New(rule.describe(name, quote)))
+      }
+    }
+  }
+  return nil
+}
+
+// ownFlag locates the flag of `cmd` itself named `name`, ignoring aliases and
+// inherited flags.
+func (self *command) ownFlag(name string) *flagDef {
+  for _, flag := range self.Flags {
+    if flag.Name == name {
+      return flag
+    }
+  }
+  return nil
+}
+
+// joinList joins `vs` as a list ending with `conj`, such as "a, b and c".
+func joinList(vs []string, conj string) string {
+  if len(vs) < 2 {
+    return strings.Join(vs, "")
+  }
+  return strings.Join(vs[:len(vs)-1], ", ") + " " + conj + " " + vs[len(vs)-1]
+}
@@ -0,0 +1,101 @@
+package basicli
+
+import (
+  "errors"
+  "os"
+  "reflect"
+  "strings"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockRules struct {
+  File   string `basicli:"file"`
+  Stdin  bool   `basicli:"stdin"`
+  Key    string `basicli:"tls-key"`
+  Cert   string `basicli:"tls-cert,env=BASICLI_TEST_CERT"`
+  Cloud  string `basicli:"cloud,default=gcp"`
+  Region string `basicli:"region"`
+  All    bool   `basicli:"all"`
+  Name   string `basicli:"name"`
+  Status MockRulesStatus
+}
+
+func (MockRules) Rules() []Rule {
+  return []Rule{
+    Exclusive("file", "stdin"),
+    Requires("tls-key", "tls-cert"),
+    RequiredIf("region", "cloud", "aws"),
+    AtLeastOne("all", "name"),
+  }
+}
+
+func (MockRules) Exec() error { return nil }
+
+type MockRulesStatus struct{}
+
+func (MockRulesStatus) Exec() error { return nil }
+
+type MockRulesBad struct {
+  File string `basicli:"file"`
+}
+
+func (MockRulesBad) Rules() []Rule {
+  return []Rule{Exclusive("file", "stdn"), AtLeastOne("file")}
+}
+
+func TestRules(t *testing.T) {
+  run := func(args ...string) error {
+    os.Args = append([]string{"app"}, args...)
+    return Run(&MockRules{})
+  }
+
+  // (good) Rules satisfied
+  assert.NilError(t, run("--name", "x", "--file", "a.txt"))
+  assert.NilError(t, run("--all", "--stdin", "--tls-key", "k", "--tls-cert", "c"))
+  assert.NilError(t, run("--all", "--cloud", "aws", "--region", "us-east-1"))
+
+  // (good) Only the rules of the command addressed demand flags
+  assert.NilError(t, run("status"))
+
+  // (bad) Rules violated
+  for _, tc := range []struct {
+    args []string
+    msg  string
+  }{
+    {[]string{"--all", "--file", "a.txt", "--stdin"}, "flags [file] and [stdin] are mutually exclusive"},
+    {[]string{"--all", "--tls-key", "k"}, "flag [tls-key] requires flag [tls-cert]"},
+    {[]string{"--all", "--cloud", "aws"}, "flag [region] is required when flag [cloud] is ['aws']"},
+    {nil, "at least one of flags [all] or [name] is required"},
+    {[]string{"--file", "a.txt", "--stdin", "status"}, "flags [file] and [stdin] are mutually exclusive"},
+  } {
+    err := run(tc.args...)
+    assert.Error(t, err, tc.msg)
+    var usage *UsageError
+    assert.Check(t, errors.As(err, &usage))
+  }
+
+  // (good) Flags provided through the environment count, defaults don't
+  t.Setenv("BASICLI_TEST_CERT", "c")
+  assert.NilError(t, run("--all", "--tls-key", "k"))
+
+  // (bad) Rules referring to unknown flags
+  assert.Error(t, Validate(&MockRulesBad{}), `found 2 problem(s) in command tree:
+  - ['app'] rule ['Exclusive'] refers to unknown flag ['--stdn']
+  - ['app'] rule ['AtLeastOne'] refers to fewer than two flags`)
+
+  // Rules are documented
+  var b strings.Builder
+  root, err := newCommand(newConfig(nil), reflect.TypeFor[MockRules](), "app")
+  assert.NilError(t, err)
+  writeDoc(&b, root)
+  assert.Check(t, strings.Contains(b.String(), strings.Join([]string{
+    "## Flag rules",
+    "",
+    "- Flags `--file` and `--stdin` are mutually exclusive",
+    "- Flag `--tls-key` requires flag `--tls-cert`",
+    "- Flag `--region` is required when flag `--cloud` is `aws`",
+    "- At least one of flags `--all` or `--name` is required",
+  }, "\n")), b.String())
+}
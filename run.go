@@ -1,9 +1,37 @@
 package basicli
 
-func Run[P *T, T any](v P) error {
-  if err := Unmarshal(v); err != nil {
+import (
+  "os"
+  "path/filepath"
+  "reflect"
+)
+
+// Run validates the command tree described by `v` (see `Validate`), unmarshals
+// `os.Args` into it, then dispatches the command addressed.
+//
+// The context passed to commands is cancelled when the process receives SIGINT
+// or SIGTERM, in which case `Run` returns an `*InterruptError` once the command
+// has returned. A second signal, or the command outliving the grace period (see
+// `WithGracePeriod`), terminates the process with exit code 130. Commands whose
+// method doesn't accept a `context.Context` can't observe the cancellation, so
+// for them the first signal terminates the process right away.
+//
+// A panic while unmarshalling or running the command is recovered, and returned
+// as a `*PanicError`.
+func Run[P *T, T any](v P, opts ...Option) (err error) {
+  defer recovered(&err, []string{filepath.Base(os.Args[0])})
+
+  cfg := newConfig(opts)
+  cmd := addressed(cfg, reflect.TypeOf(v))
+  ctx, stop := notifyContext(cfg.ctx, cfg.gracePeriod, cmd == nil || !cmd.accepts(typeContext))
+  defer stop()
+  opts = append(opts[:len(opts):len(opts)], WithContext(ctx))
+
+  if err = Validate(v, opts...); err != nil {
+    return err
+  } else if err = Unmarshal(v, opts...); err != nil {
     return err
-  } else if err = Dispatch(v); err != nil {
+  } else if err = interrupted(ctx, Dispatch(v, opts...)); err != nil {
     return err
   } else {
     return nil
@@ -0,0 +1,11 @@
+package basicli
+
+import "os"
+
+// runArgs runs a fresh `T` with command-line args `args`, returning it along
+// with the error `Run` returned.
+func runArgs[T any](args ...string) (*T, error) {
+  var v T
+  os.Args = append([]string{"app"}, args...)
+  return &v, Run(&v)
+}
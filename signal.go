@@ -0,0 +1,74 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "os"
+  "os/signal"
+  "syscall"
+  "time"
+)
+
+// exitInterrupted is the exit code of a process whose command was interrupted.
+const exitInterrupted = 130
+
+// exit terminates the process, swapped out in tests.
+var exit = os.Exit
+
+// notifyContext derives a context from `parent` which is cancelled, with an
+// `*InterruptError` cause, when the process receives SIGINT or SIGTERM.
+//
+// Once cancelled, a second signal terminates the process immediately with exit
+// code 130, as does the command failing to return within `grace` (when
+// non-zero). With `immediate`, for commands which can't observe the context,
+// the first signal terminates the process. The returned function stops
+// listening for signals.
+func notifyContext(parent context.Context, grace time.Duration, immediate bool) (context.Context, func()) {
+  ctx, cancel := context.WithCancelCause(parent)
+  signals := make(chan os.Signal, 2)
+  signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+  done := make(chan struct{})
+
+  go func() {
+    select {
+    case sig := <-signals:
+      if immediate {
+        exit(exitInterrupted)
+        return
+      }
+      cancel(&InterruptError{Signal: sig})
+    case <-done:
+      return
+    }
+
+    var timeout <-chan time.Time
+    if grace > 0 {
+      timer := time.NewTimer(grace)
+      defer timer.Stop()
+      timeout = timer.C
+    }
+    select {
+    case <-signals:
+      exit(exitInterrupted)
+    case <-timeout:
+      exit(exitInterrupted)
+    case <-done:
+    }
+  }()
+
+  return ctx, func() {
+    signal.Stop(signals)
+    close(done)
+    cancel(nil)
+  }
+}
+
+// interrupted wraps command error `err` in an `*InterruptError` when `ctx` was
+// cancelled by a signal.
+func interrupted(ctx context.Context, err error) error {
+  var interrupt *InterruptError
+  if errors.As(context.Cause(ctx), &interrupt) {
+    return &InterruptError{Signal: interrupt.Signal, Err: err}
+  }
+  return err
+}
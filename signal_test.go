@@ -0,0 +1,108 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "os"
+  "syscall"
+  "testing"
+  "time"
+
+  "gotest.tools/v3/assert"
+)
+
+type MockSignal struct {
+  Stuck MockSignalStuck
+  Plain MockSignalPlain
+}
+
+var mockSignalFlushed bool
+
+func (MockSignal) Exec(ctx context.Context) error {
+  if err := raise(os.Interrupt); err != nil {
+    return err
+  }
+  <-ctx.Done()
+  mockSignalFlushed = true
+  return ctx.Err()
+}
+
+type MockSignalStuck struct{}
+
+func (MockSignalStuck) Exec(ctx context.Context) error {
+  if err := raise(syscall.SIGTERM); err != nil {
+    return err
+  }
+  <-ctx.Done()
+  time.Sleep(time.Second)
+  return nil
+}
+
+type MockSignalPlain struct{}
+
+func (MockSignalPlain) Exec() error {
+  if err := raise(os.Interrupt); err != nil {
+    return err
+  }
+  time.Sleep(time.Second / 2)
+  return nil
+}
+
+// raise sends `sig` to the test process itself.
+func raise(sig os.Signal) error {
+  p, err := os.FindProcess(os.Getpid())
+  if err != nil {
+    return err
+  }
+  return p.Signal(sig)
+}
+
+func TestRunInterrupt(t *testing.T) {
+  // The command observes cancellation and returns, which surfaces as an
+  // `*InterruptError`
+  var ms MockSignal
+  os.Args = []string{""}
+  err := Run(&ms)
+  var interrupt *InterruptError
+  assert.Assert(t, errors.As(err, &interrupt))
+  assert.Equal(t, interrupt.Signal, os.Interrupt)
+  assert.Equal(t, interrupt.ExitCode(), 130)
+  assert.Check(t, errors.Is(err, context.Canceled))
+  assert.Error(t, err, "interrupted by signal [interrupt]")
+  assert.Check(t, mockSignalFlushed)
+}
+
+func TestRunGracePeriod(t *testing.T) {
+  // A command outliving the grace period forces an exit
+  code := make(chan int, 1)
+  exit = func(c int) { code <- c }
+  defer func() { exit = os.Exit }()
+
+  var ms MockSignal
+  os.Args = []string{"", "stuck"}
+  go Run(&ms, WithGracePeriod(10*time.Millisecond))
+  select {
+  case c := <-code:
+    assert.Equal(t, c, 130)
+  case <-time.After(time.Second / 2):
+    t.Fatal("expected a forced exit")
+  }
+}
+
+func TestRunInterruptPlain(t *testing.T) {
+  // A command which can't observe cancellation is terminated by the first
+  // signal, without waiting for a grace period
+  code := make(chan int, 1)
+  exit = func(c int) { code <- c }
+  defer func() { exit = os.Exit }()
+
+  var ms MockSignal
+  os.Args = []string{"", "plain"}
+  go Run(&ms)
+  select {
+  case c := <-code:
+    assert.Equal(t, c, 130)
+  case <-time.After(time.Second / 4):
+    t.Fatal("expected an immediate exit")
+  }
+}
@@ -0,0 +1,89 @@
+package basicli
+
+import (
+  "slices"
+  "strings"
+)
+
+// suggest produces the names among `candidates` closest to `name` by edit
+// distance, nearest first. Names which would need most of their characters
+// changed are never suggested.
+func suggest(name string, candidates []string, fold bool) []string {
+  type scored struct {
+    name string
+    d    int
+  }
+
+  limit := max(1, len(name)/3)
+  var matches []scored
+  for _, candidate := range candidates {
+    a, b := name, candidate
+    if fold {
+      a, b = strings.ToLower(a), strings.ToLower(b)
+    }
+    d := distance(a, b)
+    if d > limit || d >= len(name) {
+      continue
+    }
+    if slices.ContainsFunc(matches, func(m scored) bool { return m.name == candidate }) {
+      continue
+    }
+    matches = append(matches, scored{candidate, d})
+  }
+
+  slices.SortStableFunc(matches, func(a, b scored) int { return a.d - b.d })
+  names := make([]string, len(matches))
+  for i, m := range matches {
+    names[i] = m.name
+  }
+  return names
+}
+
+// distance computes the optimal string alignment distance between `a` and `b`:
+// the number of single-byte insertions, deletions, substitutions and adjacent
+// transpositions needed to turn one into the other.
+func distance(a, b string) int {
+  // Three rolling rows of the classic dynamic programming table
+  prev2 := make([]int, len(b)+1)
+  prev := make([]int, len(b)+1)
+  cur := make([]int, len(b)+1)
+  for j := range prev {
+    prev[j] = j
+  }
+
+  for i := 1; i <= len(a); i++ {
+    cur[0] = i
+    for j := 1; j <= len(b); j++ {
+      cost := 1
+      if a[i-1] == b[j-1] {
+        cost = 0
+      }
+      cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+      if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+        cur[j] = min(cur[j], prev2[j-2]+1)
+      }
+    }
+    prev2, prev, cur = prev, cur, prev2
+  }
+  return prev[len(b)]
+}
+
+// commandNames lists every name the immediate subcommands of `cmd` may be
+// referred to by.
+func commandNames(cmd *command) []string {
+  var names []string
+  for _, child := range cmd.Commands {
+    names = append(names, child.Name)
+    names = append(names, child.Aliases...)
+  }
+  return names
+}
+
+// flagNames lists every name the flags of `cmd` may be referred to by.
+func flagNames(cmd *command) []string {
+  var names []string
+  for _, flag := range cmd.visibleFlags() {
+    names = append(names, flag.names()...)
+  }
+  return names
+}
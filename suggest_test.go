@@ -0,0 +1,50 @@
+package basicli
+
+import (
+  "errors"
+  "os"
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+func TestDistance(t *testing.T) {
+  assert.Equal(t, distance("", ""), 0)
+  assert.Equal(t, distance("deploy", "deploy"), 0)
+  assert.Equal(t, distance("", "abc"), 3)
+  assert.Equal(t, distance("depoly", "deploy"), 1) // Transposition
+  assert.Equal(t, distance("kitten", "sitting"), 3)
+}
+
+func TestSuggest(t *testing.T) {
+  candidates := []string{"deploy", "describe", "delete", "status", "d"}
+  assert.DeepEqual(t, suggest("depoly", candidates, false), []string{"deploy"})
+  assert.DeepEqual(t, suggest("DEPLY", candidates, true), []string{"deploy"})
+  assert.DeepEqual(t, suggest("deletee", candidates, false), []string{"delete"})
+  // Too far from anything to be useful
+  assert.Check(t, len(suggest("x", candidates, false)) == 0)
+  assert.Check(t, len(suggest("zzzzzz", candidates, false)) == 0)
+}
+
+func TestUnknownError(t *testing.T) {
+  var md MockDispatch
+
+  // Unknown subcommand
+  os.Args = []string{"", "cdm", "hello"}
+  err := Dispatch(&md)
+  assert.Error(t, err, "unknown command 'cdm', did you mean 'cmd'?")
+  var unknown *UnknownError
+  assert.Assert(t, errors.As(err, &unknown))
+  assert.Equal(t, unknown.Kind, "command")
+  assert.Equal(t, unknown.Name, "cdm")
+  assert.DeepEqual(t, unknown.Suggestions, []string{"cmd"})
+
+  // Unknown flag
+  var sample Sample
+  os.Args = []string{"", "--silent", "--pth", "hello"}
+  err = Unmarshal(&sample)
+  assert.Error(t, err, "unknown flag '--pth', did you mean '--path'?")
+  assert.Assert(t, errors.As(err, &unknown))
+  assert.Equal(t, unknown.Kind, "flag")
+  assert.DeepEqual(t, unknown.Suggestions, []string{"path"})
+}
@@ -1,4 +1,47 @@
 // Package tag provides functionality for efficiently parsing struct tags.
 //
-// TODO: More details!
+// A tag is a comma-separated list of names and `key=value` directives, in any
+// order. The first name is the tag's name, and any further names its aliases:
+//
+//  `basicli:"region,r,env=APP_REGION,default=us,help='Region to deploy to'"`
+//
+// The grammar, in EBNF:
+//
+//  tag       = item { "," item } .
+//  item      = name | directive .
+//  name      = byte { byte } .
+//  directive = key "=" value .
+//  key       = "default" | "required" | "env" | "pos" | "persistent" | "help" |
+//              "choices" | "min" | "max" | "minlen" | "maxlen" | "pattern" |
+//              "prefix" .
+//  value     = byte { byte } | quoted .
+//  quoted    = "'" { qbyte | "\" any } "'" .
+//  byte      = any - "," - "=" .
+//  qbyte     = any - "'" - "\" .
+//
+// Unquoted values run to the next ',' and may contain '=' but not ','. Quoted
+// values may contain anything: a backslash escapes the byte which follows it,
+// so `\'` is a quote and `\\` a backslash. Backslashes in unquoted values have
+// no special meaning, and quoted values must be followed by ',' or the end of
+// the tag.
+//
+// The directives are:
+//
+//  default=<value>     Value used when none is provided
+//  required=<bool>     Whether a value must be provided (`true` or `false`)
+//  env=<name>          Environment variable providing a value
+//  pos=<n>|rest        Binds the n-th (zero-based) or remaining positional args
+//  persistent=<bool>   Whether subcommands accept the flag too, which a bare
+//                      `persistent` name also declares
+//  help=<text>         Description of the field, for documentation
+//  choices=<a|b|...>   The only values accepted, separated by '|'
+//  min=<n>, max=<n>    Bounds of numeric values
+//  minlen=<n>          Minimum length of strings, or number of slice elements
+//  maxlen=<n>          Maximum length of strings, or number of slice elements
+//  pattern=<regexp>    Regular expression strings must match
+//  prefix=<name>       Marks a struct field as a group of options named
+//                      `<name>.<option>`, rather than a subcommand
+//
+// A tag named `-` excludes its field, and `-,parent` marks a field populated
+// with its command's nearest ancestor of the field's type.
 package tag
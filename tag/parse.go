@@ -1,17 +1,44 @@
 package tag
 
-func Parse(v string) tag {
-  var t tag
+import "fmt"
+
+// SyntaxError reports a malformed tag, along with the byte offset into the tag
+// at which the problem was found.
+type SyntaxError struct {
+  Offset int
+  Msg    string
+}
+
+func (self *SyntaxError) Error() string {
+  return fmt.Sprintf("offset %d: %s", self.Offset, self.Msg)
+}
+
+// Parse parses tag `v` up to anything malformed, which is ignored. Use
+// `ParseStrict` to find out what that was.
+func Parse(v string) Tag {
+  t, _ := ParseStrict(v)
+  return t
+}
+
+// ParseStrict parses tag `v`, returning a `*SyntaxError` for unknown
+// directives, directives with missing or invalid values and empty names or
+// aliases.
+func ParseStrict(v string) (Tag, error) {
+  var t Tag
   if len(v) == 0 {
-    return t
+    return t, nil
   }
 
   scanner := tagScanner{v: v, i: -1}
+  scanner.markers = scanner.buf[:0]
 
   for {
     next := scanner.peek(1)
     if next == '\x00' {
       scanner.adv()
+      if v[len(v)-1] == ',' {
+        return scanner.fail(&t, &SyntaxError{Offset: len(v), Msg: "empty name or alias"})
+      }
       scanner.mark(markerID)
       break
     }
@@ -30,18 +57,50 @@ func Parse(v string) tag {
       } else if buffered == "required" {
         markerKind = markerRequired
 
+      } else if buffered == "env" {
+        markerKind = markerEnv
+
+      } else if buffered == "pos" {
+        markerKind = markerPos
+
+      } else if buffered == "persistent" {
+        markerKind = markerPersistent
+
+      } else if buffered == "help" {
+        markerKind = markerHelp
+
+      } else if buffered == "choices" {
+        markerKind = markerChoices
+
+      } else if buffered == "prefix" {
+        markerKind = markerPrefix
+
+      } else if buffered == "min" || buffered == "max" || buffered == "minlen" || buffered == "maxlen" || buffered == "pattern" {
+        markerKind = markerLimit
+
       } else {
-        panic(buffered)
+        return scanner.fail(&t, &SyntaxError{Offset: scanner.j, Msg: fmt.Sprintf("unknown directive ['%s']", buffered)})
       }
 
       // Manually move the chains
       scanner.bump()
 
-      // Consume to ',' or EOF
+      // Quoted values run to their closing quote
+      if scanner.peek(1) == '\'' {
+        if err := scanner.quoted(markerKind); err != nil {
+          return scanner.fail(&t, err)
+        }
+        continue
+      }
+
+      // Otherwise consume to ',' or EOF
       for {
         next = scanner.peek(1)
         if next == ',' || next == '\x00' {
           scanner.adv() // ','
+          if scanner.i == scanner.j {
+            return scanner.fail(&t, &SyntaxError{Offset: scanner.j, Msg: fmt.Sprintf("missing value for directive ['%s']", buffered)})
+          }
           scanner.mark(markerKind)
           break
         }
@@ -52,6 +111,9 @@ func Parse(v string) tag {
     case next == ',':
       // Mark the name/alias
       scanner.adv()
+      if scanner.i == scanner.j {
+        return scanner.fail(&t, &SyntaxError{Offset: scanner.i, Msg: "empty name or alias"})
+      }
       scanner.mark(markerID)
 
     default:
@@ -60,7 +122,7 @@ func Parse(v string) tag {
   }
 
   // Imprint the tag and return
-  scanner.imprint(&t)
+  err := scanner.imprint(&t)
 
-  return t
+  return t, err
 }
@@ -1,6 +1,7 @@
 package tag
 
 import (
+  "errors"
   "testing"
 
   "gotest.tools/v3/assert"
@@ -32,6 +33,40 @@ func TestParse(t *testing.T) {
   tag = Parse("default=hello world")
   assert.Check(t, tag.Default == "hello world")
 
+  tag = Parse("path,env=APP_PATH,p")
+  assert.Check(t, tag.Name == "path")
+  assert.Check(t, len(tag.Aliases) == 1)
+  assert.Check(t, tag.Env == "APP_PATH")
+
+  tag = Parse("src,pos=1")
+  assert.Check(t, tag.Name == "src")
+  assert.Check(t, tag.Flags.Positional())
+  assert.Check(t, !tag.Flags.Rest())
+  assert.Check(t, tag.Pos == 1)
+
+  tag = Parse("files,pos=rest")
+  assert.Check(t, tag.Flags.Positional())
+  assert.Check(t, tag.Flags.Rest())
+
+  tag = Parse("verbose,v,persistent=true")
+  assert.Check(t, tag.Name == "verbose")
+  assert.Check(t, len(tag.Aliases) == 1)
+  assert.Check(t, tag.Flags.Persistent())
+
+  tag = Parse("verbose,persistent,v")
+  assert.Check(t, tag.Name == "verbose")
+  assert.DeepEqual(t, tag.Aliases, []string{"v"})
+  assert.Check(t, tag.Flags.Persistent())
+
+  tag = Parse("-")
+  assert.Check(t, tag.Flags.Skip())
+  assert.Check(t, !tag.Flags.Parent())
+
+  tag = Parse("-,parent")
+  assert.Check(t, tag.Flags.Skip())
+  assert.Check(t, tag.Flags.Parent())
+  assert.Check(t, len(tag.Aliases) == 0)
+
   tag = Parse("silent,default=hello,s,required=true")
   assert.Check(t, tag.Name == "silent")
   assert.Check(t, len(tag.Aliases) == 1)
@@ -41,9 +76,121 @@ func TestParse(t *testing.T) {
   assert.Check(t, tag.Flags.Required())
 }
 
+func TestParseQuoted(t *testing.T) {
+  tag, err := ParseStrict("deploy,help='Deploy, then verify',d")
+  assert.NilError(t, err)
+  assert.Check(t, tag.Name == "deploy")
+  assert.Check(t, tag.Help == "Deploy, then verify")
+  assert.DeepEqual(t, tag.Aliases, []string{"d"})
+
+  // A default which isn't last may contain commas, and may be empty
+  tag, err = ParseStrict("list,default='a,b,c',required=false")
+  assert.NilError(t, err)
+  assert.Check(t, tag.Default == "a,b,c")
+  assert.Check(t, tag.Flags.HasDefault())
+  tag, err = ParseStrict("name,default=''")
+  assert.NilError(t, err)
+  assert.Check(t, tag.Default == "")
+  assert.Check(t, tag.Flags.HasDefault())
+
+  tag, err = ParseStrict("output,o,choices=json|yaml|text,default=json")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, tag.Choices, []string{"json", "yaml", "text"})
+  assert.Check(t, tag.Default == "json")
+
+  tag, err = ParseStrict("port,min=1,max=65535")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, tag.Limits, []Limit{{"min", "1"}, {"max", "65535"}})
+  tag, err = ParseStrict("name,minlen=3,pattern='^[a-z]{1,63}$'")
+  assert.NilError(t, err)
+  assert.DeepEqual(t, tag.Limits, []Limit{{"minlen", "3"}, {"pattern", "^[a-z]{1,63}$"}})
+
+  tag, err = ParseStrict("prefix=db,help='Database options'")
+  assert.NilError(t, err)
+  assert.Check(t, tag.Name == "")
+  assert.Check(t, tag.Prefix == "db")
+
+  // Escapes
+  tag, err = ParseStrict(`msg,help='it\'s a \\ backslash'`)
+  assert.NilError(t, err)
+  assert.Check(t, tag.Help == `it's a \ backslash`, tag.Help)
+
+  // Unquoted values are unchanged, backslashes and all
+  tag, err = ParseStrict(`dir,default=C:\temp,help=it's`)
+  assert.NilError(t, err)
+  assert.Check(t, tag.Default == `C:\temp`)
+  assert.Check(t, tag.Help == "it's")
+
+  for _, tc := range []struct {
+    tag    string
+    offset int
+    msg    string
+  }{
+    {"x,help='open", 7, "unterminated quoted value"},
+    {`x,help='open\'`, 7, "unterminated quoted value"},
+    {"x,help='a'b", 10, "expected ',' after quoted value"},
+    {"port,min=one", 9, "invalid number ['one']"},
+    {"name,maxlen=-1", 12, "invalid length ['-1'], expected a non-negative integer"},
+    {"name,pattern='(['", 14, "invalid pattern ['([']: error parsing regexp: missing closing ]: `[`"},
+  } {
+    _, err := ParseStrict(tc.tag)
+    var syntax *SyntaxError
+    assert.Assert(t, errors.As(err, &syntax), tc.tag)
+    assert.Check(t, syntax.Offset == tc.offset, "%s: offset %d", tc.tag, syntax.Offset)
+    assert.Check(t, syntax.Msg == tc.msg, "%s: %s", tc.tag, syntax.Msg)
+  }
+}
+
 func BenchmarkParseTags(b *testing.B) {
-  const tagStr = "silent,s,default=hello,required=true"
-  for b.Loop() {
-    Parse(tagStr)
+  for _, bc := range []struct {
+    name string
+    tag  string
+  }{
+    {"unquoted", "silent,s,default=hello,required=true"},
+    {"quoted", "silent,s,default='hello, world',required=true,help='Silences output'"},
+    {"escaped", `silent,s,default='it\'s',required=true,help='Silences \'all\' output'`},
+  } {
+    b.Run(bc.name, func(b *testing.B) {
+      b.ReportAllocs()
+      for b.Loop() {
+        Parse(bc.tag)
+      }
+    })
   }
 }
+
+func TestParseStrict(t *testing.T) {
+  tag, err := ParseStrict("silent,s,default=hello,required=true,persistent=false")
+  assert.NilError(t, err)
+  assert.Check(t, tag.Name == "silent")
+  assert.Check(t, tag.Flags.Required())
+  assert.Check(t, !tag.Flags.Persistent())
+
+  for _, tc := range []struct {
+    tag    string
+    offset int
+    msg    string
+  }{
+    {"port,bogus=1", 5, "unknown directive ['bogus']"},
+    {"=x", 0, "unknown directive ['']"},
+    {"port,required=yes", 14, "invalid boolean ['yes'], expected 'true' or 'false'"},
+    {"port,persistent=1", 16, "invalid boolean ['1'], expected 'true' or 'false'"},
+    {"src,pos=first", 8, "invalid position ['first'], expected a non-negative integer or 'rest'"},
+    {"src,pos=-1", 8, "invalid position ['-1'], expected a non-negative integer or 'rest'"},
+    {"port,default=", 13, "missing value for directive ['default']"},
+    {"port,env=,p", 9, "missing value for directive ['env']"},
+    {",p", 0, "empty name or alias"},
+    {"port,,p", 5, "empty name or alias"},
+    {"port,", 5, "empty name or alias"},
+  } {
+    _, err := ParseStrict(tc.tag)
+    var syntax *SyntaxError
+    assert.Assert(t, errors.As(err, &syntax), tc.tag)
+    assert.Check(t, syntax.Offset == tc.offset, "%s: offset %d", tc.tag, syntax.Offset)
+    assert.Check(t, syntax.Msg == tc.msg, "%s: %s", tc.tag, syntax.Msg)
+  }
+
+  // The lenient parser ignores what it can't parse, rather than panicking
+  tag = Parse("port,bogus=1")
+  assert.Check(t, tag.Name == "port")
+}
@@ -1,9 +1,17 @@
 package tag
 
+import (
+  "fmt"
+  "regexp"
+  "strconv"
+  "strings"
+)
+
 type tagScanner struct {
   v       string
   i, j    int
   markers [][3]int
+  buf     [8][3]int // Backs markers for typical tags, sparing an allocation
 }
 
 func (self *tagScanner) peek(i int) byte {
@@ -25,8 +33,19 @@ const (
   markerID = 1 + iota
   markerRequired
   markerDefault
+  markerEnv
+  markerPos
+  markerPersistent
+  markerHelp
+  markerChoices
+  markerLimit // min, max, minlen, maxlen and pattern
+  markerPrefix
 )
 
+// markerQuoted is set on the kind of markers for quoted values, whose escapes
+// are resolved on imprint.
+const markerQuoted = 1 << 8
+
 func (self *tagScanner) mark(kind int) {
   if self.i <= self.j {
     return
@@ -35,6 +54,39 @@ func (self *tagScanner) mark(kind int) {
   self.bump()
 }
 
+// quoted consumes a quoted value of directive `kind` beginning at the next
+// byte, along with the ',' (or EOF) which must follow it. The value is marked
+// without its quotes, even when empty.
+func (self *tagScanner) quoted(kind int) error {
+  open := self.i + 1
+  self.adv() // '\''
+  self.bump()
+  for {
+    switch self.peek(1) {
+    case '\x00':
+      return &SyntaxError{Offset: open, Msg: "unterminated quoted value"}
+
+    case '\\':
+      // Skip the escaped byte, whatever it is
+      self.adv()
+      self.adv()
+
+    case '\'':
+      self.adv()
+      self.markers = append(self.markers, [3]int{kind | markerQuoted, self.j, self.i})
+      if next := self.peek(1); next != ',' && next != '\x00' {
+        return &SyntaxError{Offset: self.i + 1, Msg: "expected ',' after quoted value"}
+      }
+      self.adv() // ','
+      self.bump()
+      return nil
+
+    default:
+      self.adv()
+    }
+  }
+}
+
 func (self *tagScanner) bump() {
   self.j = self.i + 1
 }
@@ -52,34 +104,151 @@ func (self *tagScanner) buffered() string {
   return self.v[self.j:self.i]
 }
 
-func (self *tagScanner) imprint(tag *tag) {
+func (self *tagScanner) imprint(tag *Tag) error {
   if tag == nil {
-    return
+    return nil
   }
 
   for _, marker := range self.markers {
     kind, low, high := marker[0], marker[1], marker[2]
-    if low < 0 || low > high || high >= len(self.v) {
+    if low < 0 || low > high || high > len(self.v) {
       continue
     }
     v := self.v[low:high]
+    if kind&markerQuoted != 0 {
+      kind &^= markerQuoted
+      v = unescape(v)
+    }
 
     switch kind {
     case markerID:
-      if len(tag.Name) == 0 {
+      switch {
+      case len(tag.Name) == 0:
         tag.Name = v
-      } else {
+        if v == "-" {
+          tag.Flags |= flagSkip
+        }
+      case tag.Flags.Skip() && v == "parent":
+        tag.Flags |= flagParent
+      case v == "persistent":
+        tag.Flags |= flagPersistent
+      default:
         tag.Aliases = append(tag.Aliases, v)
       }
 
     case markerRequired:
-      if v == "true" {
+      required, err := parseBool(v, low)
+      if err != nil {
+        return err
+      }
+      if required {
         tag.Flags |= flagRequired
       }
 
     case markerDefault:
       tag.Default = v
       tag.Flags |= flagHasDefault
+
+    case markerEnv:
+      tag.Env = v
+
+    case markerHelp:
+      tag.Help = v
+
+    case markerPrefix:
+      tag.Prefix = v
+
+    case markerChoices:
+      tag.Choices = strings.Split(v, "|")
+
+    case markerLimit:
+      directive := self.directive(low)
+      if err := checkLimit(directive, v, low); err != nil {
+        return err
+      }
+      tag.Limits = append(tag.Limits, Limit{Directive: directive, Value: v})
+
+    case markerPersistent:
+      persistent, err := parseBool(v, low)
+      if err != nil {
+        return err
+      }
+      if persistent {
+        tag.Flags |= flagPersistent
+      }
+
+    case markerPos:
+      if v == "rest" {
+        tag.Flags |= flagPositional | flagRest
+      } else if pos, err := strconv.Atoi(v); err == nil && pos >= 0 {
+        tag.Pos = pos
+        tag.Flags |= flagPositional
+      } else {
+        return &SyntaxError{Offset: low, Msg: fmt.Sprintf("invalid position ['%s'], expected a non-negative integer or 'rest'", v)}
+      }
+    }
+  }
+
+  return nil
+}
+
+// directive produces the key of the directive whose value begins at offset
+// `low`, or at the quote preceding it.
+func (self *tagScanner) directive(low int) string {
+  eq := strings.LastIndexByte(self.v[:low], '=')
+  key := self.v[:eq]
+  return key[strings.LastIndexByte(key, ',')+1:]
+}
+
+// checkLimit checks value `v` of limit `directive`, found at offset `low`.
+func checkLimit(directive, v string, low int) error {
+  switch directive {
+  case "min", "max":
+    if _, err := strconv.ParseFloat(v, 64); err != nil {
+      return &SyntaxError{Offset: low, Msg: fmt.Sprintf("invalid number ['%s']", v)}
+    }
+  case "minlen", "maxlen":
+    if n, err := strconv.Atoi(v); err != nil || n < 0 {
+      return &SyntaxError{Offset: low, Msg: fmt.Sprintf("invalid length ['%s'], expected a non-negative integer", v)}
     }
+  case "pattern":
+    if _, err := regexp.Compile(v); err != nil {
+      return &SyntaxError{Offset: low, Msg: fmt.Sprintf("invalid pattern ['%s']: %s", v, err)}
+    }
+  }
+  return nil
+}
+
+// unescape resolves the backslash escapes of quoted value `v`, only allocating
+// when there are any.
+func unescape(v string) string {
+  if strings.IndexByte(v, '\\') < 0 {
+    return v
+  }
+  var b strings.Builder
+  b.Grow(len(v))
+  for i := 0; i < len(v); i++ {
+    if v[i] == '\\' && i+1 < len(v) {
+      i++
+    }
+    b.WriteByte(v[i])
+  }
+  return b.String()
+}
+
+// fail imprints what was parsed ahead of `err` onto `tag`, returning both.
+func (self *tagScanner) fail(tag *Tag, err error) (Tag, error) {
+  self.imprint(tag)
+  return *tag, err
+}
+
+// parseBool parses the boolean value `v` of a directive, found at offset `low`.
+func parseBool(v string, low int) (bool, error) {
+  switch v {
+  case "true":
+    return true, nil
+  case "false":
+    return false, nil
   }
+  return false, &SyntaxError{Offset: low, Msg: fmt.Sprintf("invalid boolean ['%s'], expected 'true' or 'false'", v)}
 }
@@ -0,0 +1,17 @@
+package tag
+
+import (
+  "testing"
+
+  "gotest.tools/v3/assert"
+)
+
+func TestScanFinalItem(t *testing.T) {
+  // The final item runs to the end of the tag, and is kept like any other
+  tag := Parse("name")
+  assert.Equal(t, tag.Name, "name")
+  tag = Parse("name,alias")
+  assert.DeepEqual(t, tag.Aliases, []string{"alias"})
+  tag = Parse("name,default=value")
+  assert.Equal(t, tag.Default, "value")
+}
@@ -1,17 +1,37 @@
 package tag
 
-type tag struct {
+// Tag is a parsed struct tag. The first name is the tag's name, and the rest
+// are its aliases.
+type Tag struct {
   Name    string
   Aliases []string
   Default string
+  Env     string
+  Help    string
+  Prefix  string
+  Choices []string
+  Limits  []Limit
+  Pos     int
   Flags   tagFlags
 }
 
+// Limit is a constraint on the values of a field: a `min`, `max`, `minlen`,
+// `maxlen` or `pattern` directive.
+type Limit struct {
+  Directive string
+  Value     string
+}
+
 type tagFlags uint8
 
 const (
   flagRequired tagFlags = 1 << iota
   flagHasDefault
+  flagPositional
+  flagRest
+  flagSkip
+  flagParent
+  flagPersistent
 )
 
 func (self tagFlags) Required() bool {
@@ -21,3 +41,33 @@ func (self tagFlags) Required() bool {
 func (self tagFlags) HasDefault() bool {
   return self&flagHasDefault == flagHasDefault
 }
+
+// Positional reports whether the tag binds a positional arg (`pos=N` or
+// `pos=rest`) rather than a flag.
+func (self tagFlags) Positional() bool {
+  return self&flagPositional == flagPositional
+}
+
+// Rest reports whether the tag binds all remaining positional args
+// (`pos=rest`).
+func (self tagFlags) Rest() bool {
+  return self&flagRest == flagRest
+}
+
+// Skip reports whether the tag is named `-`, excluding the field from the
+// command's flags and subcommands.
+func (self tagFlags) Skip() bool {
+  return self&flagSkip == flagSkip
+}
+
+// Parent reports whether a skipped field is instead populated with a pointer to
+// the nearest ancestor command of its type (`-,parent`).
+func (self tagFlags) Parent() bool {
+  return self&flagParent == flagParent
+}
+
+// Persistent reports whether the flag is also accepted by every subcommand of
+// the command declaring it (`persistent` or `persistent=true`).
+func (self tagFlags) Persistent() bool {
+  return self&flagPersistent == flagPersistent
+}
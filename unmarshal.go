@@ -3,17 +3,18 @@ package basicli
 import (
   "fmt"
   "os"
+  "path/filepath"
   "reflect"
-  "slices"
   "strconv"
   "strings"
-
-  "github.com/illbjorn/basicli/argv"
-  "github.com/illbjorn/basicli/tag"
 )
 
-// Unmarshal `os.Args` input to provided `P` instance `v`.
-func Unmarshal[P *T, T any](v P) error {
+// Unmarshal `os.Args` input to provided `P` instance `v`, then calls the
+// `Validate() error` hook of each struct along the subcommand path, if
+// declared.
+func Unmarshal[P *T, T any](v P, opts ...Option) error {
+  cfg := newConfig(opts)
+
   // Must be a non-nil pointer
   if v == nil {
     var v T
@@ -26,99 +27,103 @@ func Unmarshal[P *T, T any](v P) error {
     return fmt.Errorf("expected struct, found [%s]", rv.Kind())
   }
 
-  // Parse args and flags
-  args, flags := argv.Parse(os.Args[1:])
+  // Built-in commands have nothing to unmarshal
+  root, err := newCommand(cfg, rv.Type(), filepath.Base(os.Args[0]))
+  if err != nil {
+    return err
+  }
+  if _, ok := builtin(cfg, root, os.Args[1:]); ok {
+    return nil
+  }
+
+  // Locate the (sub)command addressed, and the flags of each command along the
+  // way
+  l, err := parseLine(cfg, root, rv, os.Args[1:])
+  if err != nil {
+    return err
+  }
+  cmd, rv := leaf(l.path).cmd, leaf(l.path).rv
+  if len(l.args) > 0 && !cmd.takesArgs() {
+    // We failed to locate a nested member for the referenced subcommand
+    return unknownCommand(cmd, l.args[0])
+  }
 
-  // Unmarshal and return
-  return unmarshal(rv, args, flags, []string{})
+  // Unmarshal flags into each struct along the path, then any remaining
+  // positional args, before letting each struct validate itself
+  path := structs(l.path)
+  for i, f := range path {
+    if err := unmarshal(f.cmd, f.rv, l.flags[i], i == len(path)-1); err != nil {
+      return err
+    }
+  }
+  if err := bindArgs(cmd, rv, l.args); err != nil {
+    return err
+  }
+  return validated(path)
 }
 
-// unmarshal recursively consumes input `args`, locating a nested struct on `rv`
-// with a case-insensitively-matching name. When found, `unmarshal` recurses to
-// that nested struct until `args` is empty.
+// unmarshal assigns the flags of `cmd` on its struct `rv`, converting the
+// string values of `flags` (keyed by flag name) to the data type of each field.
+// Flags not provided fall back to their environment variable, then their
+// default value. Values bound from any source are then checked against the
+// limits of their flag, and the flags provided against the rules of `cmd`.
 //
-// Once `args` is empty, the leaf struct fields are iterated and any flag values
-// contained in `flags` which match either the struct field name or struct field
-// tag value(s) exactly have the respective provided flag value assigned. This
-// assignment includes conversion of the string input to the data type of the
-// field.
-func unmarshal(rv reflect.Value, args []string, flags map[string][]string, found []string) error {
-  // If we have positional args, locate the nested struct and recurse
-  if len(args) > 0 {
-    // Slice off the first arg
-    arg := args[0]
-    args = args[1:]
-    // Iterate struct fields
-    for i := range rv.NumField() {
-      ft := rv.Type().Field(i)
-      // Look for a struct tag
-      t, ok := ft.Tag.Lookup(structTag)
-      if ok {
-        // Look for a match against the next arg
-        parsed := tag.Parse(t)
-        if slices.Contains(append(parsed.Aliases, parsed.Name), arg) {
-          // Recurse
-          return unmarshal(rv.Field(i), args, flags, found)
-        }
-      }
-      // Look for a case-insensitive match against the field name itself
-      if len(ft.Name) != len(arg) {
-        continue
-      }
-      if strings.EqualFold(ft.Name, arg) {
-        // Recurse
-        return unmarshal(rv.Field(i), args, flags, found)
-      }
+// Required flags must be provided when `cmd` is the command addressed, while
+// those of its ancestors are only required when persistent.
+func unmarshal(cmd *command, rv reflect.Value, flags map[string][]string, addressed bool) error {
+  for _, flag := range cmd.Flags {
+    field := fieldByIndex(rv, flag.Field.Index)
+    bound, err := bindFlag(flag, field, flags, addressed)
+    if err != nil {
+      return err
     }
-    // We failed to locate a nested member for the referenced subcommand
-    return fmt.Errorf("failed to locate subcommand [%s]", arg)
-  }
-
-  // Otherwise, we're just here to populate field values
-next:
-  for i := range rv.NumField() {
-    ft := rv.Type().Field(i)
-    found = append(found, ft.Name)
-    // Check for a direct match on field name
-    //
-    // NOTE: Flags are case-sensitive, while subcommands are not
-    flag, ok := flags[ft.Name]
-    if ok {
-      // Set the field value
-      fieldSet(rv.Field(i), flag)
+    if !bound {
+      continue
+    }
+    if err := constrain("flag", flag.Name, flag.Limits, field); err != nil {
+      return err
     }
-    // Also look for a struct tag
-    t, ok := ft.Tag.Lookup(structTag)
-    if ok {
-      tag := tag.Parse(t)
-      // Register "found" flags
-      found = append(found, tag.Name)
-      found = append(found, tag.Aliases...)
-      // Look for a provided flag value which matches
-      for _, name := range append(tag.Aliases, tag.Name) {
-        flag, ok := flags[name]
-        if ok {
-          // Set the field value
-          fieldSet(rv.Field(i), flag)
-          continue next
-        }
+  }
+  return checkRules(cmd, rv, flags, addressed)
+}
+
+// bindFlag assigns `flag` on `field`, from `flags`, its environment variable or
+// its default value, reporting whether it found a value to assign.
+func bindFlag(flag *flagDef, field reflect.Value, flags map[string][]string, addressed bool) (bool, error) {
+  if vs, ok := flags[flag.Name]; ok {
+    if err := choose(flag.Choices, vs); err != nil {
+      return false, invalidValue("flag", flag.Name, []string{err.Value}, err)
+    }
+    if err := fieldSet(field, vs); err != nil {
+      return false, invalidValue("flag", flag.Name, vs, err)
+    }
+    return true, nil
+  }
+
+  // Fall back to a bound environment variable, then to the default value
+  if len(flag.Env) > 0 {
+    if v, ok := os.LookupEnv(flag.Env); ok {
+      if err := choose(flag.Choices, []string{v}); err != nil {
+        return false, usageError(fmt.Errorf("failed to set flag [%s] from environment variable [%s]: %w", flag.Name, flag.Env, err))
       }
-      // If we made it here and the tag is required, we have a problem
-      if tag.Flags.Required() {
-        fmt.Printf("%#v\n", flags)
-        return fmt.Errorf("flag [%s] is required but was not provided", tag.Name)
+      if err := fieldSet(field, []string{v}); err != nil {
+        return false, usageError(fmt.Errorf("failed to set flag [%s] from environment variable [%s]: %w", flag.Name, flag.Env, err))
       }
+      return true, nil
     }
   }
-
-  // Confirm we didn't encounter any flags which were not defined on the struct
-  for k := range flags {
-    if !slices.Contains(found, k) {
-      return fmt.Errorf("received unexpected flag [%s]", k)
+  if flag.HasDefault {
+    if err := fieldSet(field, []string{flag.Default}); err != nil {
+      return false, fmt.Errorf("failed to set flag [%s] to default value [%s]: %w", flag.Name, flag.Default, err)
     }
+    return true, nil
   }
 
-  return nil
+  // If we made it here and the flag is required, we have a problem
+  if flag.Required && (addressed || flag.Persistent) {
+    return false, usageError(fmt.Errorf("flag [%s] is required but was not provided", flag.Name))
+  }
+  return false, nil
 }
 
 // fieldSet evaluates the type of the struct field contained in `rv`, converting
@@ -129,6 +134,9 @@ func fieldSet(rv reflect.Value, vs []string) error {
   }
 
   rv = Concrete(rv)
+  if !rv.IsValid() {
+    return fmt.Errorf("found unallocated field")
+  }
   rt := rv.Type()
 
   if !rv.CanAddr() {
@@ -158,6 +166,15 @@ func fieldSet(rv reflect.Value, vs []string) error {
     }
     rv.SetUint(ui)
 
+  case reflect.Slice:
+    slice := reflect.MakeSlice(rt, len(vs), len(vs))
+    for i, v := range vs {
+      if err := fieldSet(slice.Index(i), []string{v}); err != nil {
+        return err
+      }
+    }
+    rv.Set(slice)
+
   default:
     return fmt.Errorf("found unexpected struct field kind [%s]", rv.Kind())
   }
@@ -41,11 +41,36 @@ func TestUnmarshal(t *testing.T) {
   os.Args = []string{
     "", "--silent", "--debug", "--path", "hello", "-x",
   }
-  assert.Error(t, Unmarshal(&sample), "received unexpected flag [x]")
+  assert.Error(t, Unmarshal(&sample), "unknown flag '-x'")
 
   // (bad) Subcommand reference which does not exist
   os.Args = []string{
     "", "a", "b", "--silent", "--debug", "--path", "hello",
   }
-  assert.Error(t, Unmarshal(&sample), "failed to locate subcommand [a]")
+  assert.Error(t, Unmarshal(&sample), "unknown command 'a'")
+}
+
+type SampleEnv struct {
+  Region string `basicli:"region,env=BASICLI_TEST_REGION,default=us"`
+  Port   int    `basicli:"port,default=8080"`
+}
+
+func TestUnmarshalEnvDefault(t *testing.T) {
+  var sample SampleEnv
+
+  // (good) Defaults apply when neither a flag nor the environment is set
+  os.Args = []string{""}
+  assert.NilError(t, Unmarshal(&sample))
+  assert.Equal(t, sample.Region, "us")
+  assert.Equal(t, sample.Port, 8080)
+
+  // (good) The environment takes precedence over the default
+  t.Setenv("BASICLI_TEST_REGION", "eu")
+  assert.NilError(t, Unmarshal(&sample))
+  assert.Equal(t, sample.Region, "eu")
+
+  // (good) The flag takes precedence over the environment
+  os.Args = []string{"", "--region", "ap"}
+  assert.NilError(t, Unmarshal(&sample))
+  assert.Equal(t, sample.Region, "ap")
 }
@@ -0,0 +1,340 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "fmt"
+  "maps"
+  "os"
+  "path/filepath"
+  "reflect"
+  "slices"
+  "strings"
+
+  "github.com/illbjorn/basicli/tag"
+)
+
+// ValidationError reports every problem `Validate` found in a command tree.
+type ValidationError struct {
+  Problems []error
+}
+
+func (self *ValidationError) Error() string {
+  var b strings.Builder
+  fmt.Fprintf(&b, "found %d problem(s) in command tree:", len(self.Problems))
+  for _, problem := range self.Problems {
+    fmt.Fprintf(&b, "\n  - %s", problem)
+  }
+  return b.String()
+}
+
+func (self *ValidationError) Unwrap() []error {
+  return self.Problems
+}
+
+// Validate checks the command tree described by `v` for mistakes which would
+// otherwise only surface once a user addresses the command concerned, returning
+// a `*ValidationError` listing all of them:
+//
+//  - Malformed tags, tags on unexported fields and unexported embedded pointers
+//  - Duplicate names or aliases among sibling commands, or among the flags a
+//    command accepts (including their Go selectors, those of embedded structs
+//    and inherited persistent flags)
+//  - Flags or positional args both required and with a default value
+//  - Flags or positional args of unsupported kinds, with limits which don't
+//    apply to their kind, or with a default value outside of their choices or
+//    limits
+//  - Flag rules referring to flags the command doesn't declare
+//  - Conflicting positional args, and positional args with a default value
+//    followed by a required one
+//  - Parent fields without a matching ancestor
+//  - Methods basicli calls (`Exec`, method leaf subcommands, hooks (including
+//    `Validate`), middleware, `Rules` and `Complete<Field>`) with unsupported
+//    signatures
+//
+// `Run` validates the tree ahead of unmarshalling. Calling `Validate` from a
+// unit test catches the same mistakes at build time:
+//
+//  func TestCLI(t *testing.T) {
+//    if err := basicli.Validate(&App{}); err != nil {
+//      t.Fatal(err)
+//    }
+//  }
+func Validate[P *T, T any](v P, opts ...Option) error {
+  rt := reflect.TypeOf(v)
+  if indirect(rt).Kind() != reflect.Struct {
+    return fmt.Errorf("received non-struct type ['%T'] in call to Validate", v)
+  }
+  cfg := newConfig(opts)
+  name := filepath.Base(os.Args[0])
+
+  // Malformed tags leave no command tree to check
+  if problems := validateTags(cfg, indirect(rt), []string{name}, map[reflect.Type]bool{}); len(problems) > 0 {
+    return &ValidationError{Problems: problems}
+  }
+
+  root, err := newCommand(cfg, rt, name)
+  if err != nil {
+    return err
+  }
+  var problems []error
+  root.walk(func(cmd *command) {
+    problems = append(problems, validateCommand(cmd)...)
+  })
+  if len(problems) > 0 {
+    return &ValidationError{Problems: problems}
+  }
+  return nil
+}
+
+// validateTags checks the tags of the fields of struct type `rt`, at `path` in
+// the command tree, and of every struct beneath it.
+func validateTags(cfg *config, rt reflect.Type, path []string, seen map[reflect.Type]bool) []error {
+  if seen[rt] {
+    return nil
+  }
+  seen[rt] = true
+
+  var problems []error
+  for i := range rt.NumField() {
+    sf := rt.Field(i)
+    v, ok := sf.Tag.Lookup(structTag)
+    embedded := sf.Anonymous && indirect(sf.Type).Kind() == reflect.Struct
+    if !sf.IsExported() && !embedded {
+      if ok {
+        problems = append(problems, problemf(path, "unexported field ['%s'] is tagged, but ignored", sf.Name))
+      }
+      continue
+    }
+    if !sf.IsExported() && sf.Type.Kind() == reflect.Pointer {
+      problems = append(problems, problemf(path, "unexported embedded pointer ['%s'] can't be allocated", sf.Name))
+      continue
+    }
+    t, err := tag.ParseStrict(v)
+    if err != nil {
+      problems = append(problems, tagError(path, sf, err))
+      continue
+    }
+    if !t.Flags.Skip() && (embedded || len(t.Prefix) > 0) {
+      problems = append(problems, validateTags(cfg, indirect(sf.Type), path, seen)...)
+    } else if !t.Flags.Skip() && indirect(sf.Type).Kind() == reflect.Struct {
+      name := t.Name
+      if len(name) == 0 {
+        name = cfg.naming(sf.Name)
+      }
+      problems = append(problems, validateTags(cfg, indirect(sf.Type), extend(path, name), seen)...)
+    }
+  }
+  return problems
+}
+
+// validateCommand checks a single command of the tree, ignoring its
+// subcommands.
+func validateCommand(cmd *command) []error {
+  var problems []error
+  add := func(format string, args ...any) {
+    problems = append(problems, problemf(cmd.Path, format, args...))
+  }
+
+  // Method leaf subcommands share the flags and args of their struct, so only
+  // their signature is theirs to check
+  if len(cmd.Method) > 0 {
+    method := methodType(cmd.Type, cmd.Method)
+    if !supportedParams(method) {
+      add("method ['%s'] has unsupported signature ['%s']", cmd.Method, method)
+    }
+    return problems
+  }
+
+  // Sibling commands
+  owners := make(map[string]string)
+  for _, child := range cmd.Commands {
+    for _, name := range append([]string{child.Name}, child.Aliases...) {
+      name = strings.ToLower(name)
+      if owner, ok := owners[name]; ok {
+        add("subcommands ['%s'] and ['%s'] share the name ['%s']", owner, child.FieldName, name)
+      }
+      owners[name] = child.FieldName
+    }
+  }
+
+  // Flags
+  owners = make(map[string]string)
+  for _, flag := range cmd.Flags {
+    for _, name := range flag.matchNames() {
+      if owner, ok := owners[name]; ok {
+        add("flags ['%s'] and ['%s'] share the name ['%s']", owner, flag.goName(), flagDisplay(name))
+      }
+      owners[name] = flag.goName()
+    }
+    if flag.Required && flag.HasDefault {
+      add("flag ['%s']: %w", flagDisplay(flag.Name), ErrRequiredAndDefault)
+    }
+    if !supportedKind(flag.Field.Type) {
+      add("flag ['%s'] has unsupported type ['%s']", flagDisplay(flag.Name), flag.Field.Type)
+    }
+    if err := choose(flag.Choices, defaults(flag.HasDefault, flag.Default)); err != nil {
+      add("flag ['%s'] has default value ['%s']: %w", flagDisplay(flag.Name), err.Value, err)
+    }
+    for _, problem := range validateLimits(flag.Limits, flag.Field.Type, "flag", flag.Name, defaults(flag.HasDefault, flag.Default)) {
+      add("flag ['%s'] %w", flagDisplay(flag.Name), problem)
+    }
+  }
+  if err := cmd.conflict(); err != nil {
+    problems = append(problems, err)
+  }
+
+  // Flag rules
+  for _, rule := range cmd.Rules {
+    if len(rule.flags) < 2 {
+      add("rule ['%s'] refers to fewer than two flags", rule.kind)
+    }
+    for _, name := range rule.flags {
+      if cmd.ownFlag(name) == nil {
+        add("rule ['%s'] refers to unknown flag ['%s']", rule.kind, flagDisplay(name))
+      }
+    }
+  }
+
+  // Positional args
+  positions := make(map[int]string)
+  var rest string
+  lo, _ := cmd.arity()
+  for _, arg := range cmd.Args {
+    if arg.Required && arg.HasDefault {
+      add("arg ['%s']: %w", argDisplay(arg), ErrRequiredAndDefault)
+    }
+    // Args are assigned in order, so a required arg following one with a
+    // default means the default never applies
+    if !arg.Rest && arg.HasDefault && arg.Pos < lo {
+      add("arg ['%s'] has a default value, but is followed by a required arg", argDisplay(arg))
+    }
+    if !supportedKind(arg.Field.Type) {
+      add("arg ['%s'] has unsupported type ['%s']", argDisplay(arg), arg.Field.Type)
+    }
+    if err := choose(arg.Choices, defaults(arg.HasDefault, arg.Default)); err != nil {
+      add("arg ['%s'] has default value ['%s']: %w", argDisplay(arg), err.Value, err)
+    }
+    for _, problem := range validateLimits(arg.Limits, arg.Field.Type, "arg", arg.Name, defaults(arg.HasDefault, arg.Default)) {
+      add("arg ['%s'] %w", argDisplay(arg), problem)
+    }
+    switch {
+    case arg.Rest && len(rest) > 0:
+      add("args ['%s'] and ['%s'] both collect the remaining args", rest, arg.Field.Name)
+    case arg.Rest && arg.Field.Type.Kind() != reflect.Slice:
+      add("arg ['%s'] collects the remaining args, but isn't a slice", argDisplay(arg))
+    case arg.Rest:
+      rest = arg.Field.Name
+    case len(positions[arg.Pos]) > 0:
+      add("args ['%s'] and ['%s'] share position [%d]", positions[arg.Pos], arg.Field.Name, arg.Pos)
+    default:
+      positions[arg.Pos] = arg.Field.Name
+    }
+  }
+
+  // Parent fields
+  for _, sf := range cmd.Parents {
+    if sf.Type.Kind() != reflect.Pointer || cmd.Parent == nil || !cmd.Parent.recursive(sf.Type.Elem()) {
+      add("parent field ['%s'] of type ['%s'] doesn't point to an ancestor command", sf.Name, sf.Type)
+    }
+  }
+
+  // Methods basicli calls
+  signatures := map[string]func(reflect.Type) bool{
+    methodExec: func(mt reflect.Type) bool {
+      return supportedParams(mt) && (mt.NumOut() == 0 || mt.NumOut() == 1 && mt.Out(0) == typeError)
+    },
+    methodBefore:     is[func(context.Context) error],
+    methodAfter:      is[func(context.Context, error) error],
+    methodMiddleware: is[func() []Middleware],
+    methodRules:      is[func() []Rule],
+    methodValidate:   is[func() error],
+  }
+  for _, flag := range cmd.Flags {
+    signatures[methodCompletePrefix+flag.Field.Name] = is[func(string) []string]
+  }
+  for _, arg := range cmd.Args {
+    signatures[methodCompletePrefix+arg.Field.Name] = is[func(string) []string]
+  }
+  for _, name := range slices.Sorted(maps.Keys(signatures)) {
+    if method := methodType(cmd.Type, name); method != nil && !signatures[name](method) {
+      add("method ['%s'] has unsupported signature ['%s']", name, method)
+    }
+  }
+
+  return problems
+}
+
+// validateLimits checks each of `limits` applies to fields of type `rt`, and
+// that default value `def` of the flag or positional arg (`kind`) `name`, if
+// any, satisfies them.
+func validateLimits(limits []limit, rt reflect.Type, kind, name string, def []string) []error {
+  var problems []error
+  for _, l := range limits {
+    if !l.applies(rt) {
+      problems = append(problems, fmt.Errorf("has directive ['%s'], which doesn't apply to type ['%s']", l, rt))
+    }
+  }
+  if len(problems) > 0 || len(def) == 0 {
+    return problems
+  }
+  rv := reflect.New(rt).Elem()
+  if err := fieldSet(rv, def); err != nil {
+    return nil
+  }
+  var constraint *ConstraintError
+  if errors.As(constrain(kind, name, limits, rv), &constraint) {
+    problems = append(problems, fmt.Errorf("has default value ['%s']: %s", def[0], constraint.expected()))
+  }
+  return problems
+}
+
+// problemf produces a problem found at `path` in the command tree.
+func problemf(path []string, format string, args ...any) error {
+  return fmt.Errorf("['%s'] "+format, append([]any{strings.Join(path, " ")}, args...)...)
+}
+
+// tagError produces a problem with the tag of field `sf`, at `path` in the
+// command tree.
+func tagError(path []string, sf reflect.StructField, err error) error {
+  return problemf(path, "field ['%s'] has malformed tag ['%s']: %w", sf.Name, sf.Tag.Get(structTag), err)
+}
+
+// methodType produces the type of method `name` of struct type `rt` (with
+// either receiver) without its receiver, or nil when there is no such method.
+func methodType(rt reflect.Type, name string) reflect.Type {
+  method := reflect.New(rt).MethodByName(name)
+  if !method.IsValid() {
+    return nil
+  }
+  return method.Type()
+}
+
+// defaults produces the default value of a flag or positional arg, if it has
+// one.
+func defaults(has bool, v string) []string {
+  if !has {
+    return nil
+  }
+  return []string{v}
+}
+
+// is reports whether `rt` is the type `T`.
+func is[T any](rt reflect.Type) bool {
+  return rt == reflect.TypeFor[T]()
+}
+
+// supportedKind reports whether `fieldSet` can assign values to fields of type
+// `rt`.
+func supportedKind(rt reflect.Type) bool {
+  if rt.Kind() == reflect.Slice {
+    rt = rt.Elem()
+  }
+  switch rt.Kind() {
+  case reflect.Bool, reflect.String,
+    reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+    reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    return true
+  }
+  return false
+}
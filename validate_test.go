@@ -0,0 +1,97 @@
+package basicli
+
+import (
+  "context"
+  "errors"
+  "os"
+  "testing"
+
+  "github.com/illbjorn/basicli/tag"
+  "gotest.tools/v3/assert"
+)
+
+type MockValidateTree struct {
+  Region string             `basicli:"region,r,required=true,default=us"`
+  Zone   string             `basicli:"r"`
+  Limits map[string]int
+  Deploy MockValidateDeploy `basicli:"deploy,ship"`
+  Ship   MockValidateShip
+  Orphan *MockValidateTree  `basicli:"-,parent"`
+}
+
+func (MockValidateTree) Before() error { return nil }
+
+func (MockValidateTree) Bad(name string) error { return nil }
+
+type MockValidateDeploy struct {
+  Src  string `basicli:"pos=0"`
+  Dst  string `basicli:"pos=0"`
+  Rest string `basicli:"pos=rest"`
+}
+
+func (MockValidateDeploy) Exec(name string) error { return nil }
+
+func (MockValidateDeploy) CompleteSrc() []string { return nil }
+
+type MockValidateShip struct{}
+
+func (MockValidateShip) After(ctx context.Context, err error) error { return err }
+
+type MockValidateMalformed struct {
+  Port   int    `basicli:"port,bogus=1"`
+  Debug  bool   `basicli:"debug,required=yes"`
+  secret string `basicli:"secret"`
+  Sub    struct {
+    Host string `basicli:"host,nope=x"`
+  }
+}
+
+func TestValidate(t *testing.T) {
+  os.Args = []string{"app"}
+
+  // (good) Well-formed trees
+  assert.NilError(t, Validate(&MockHooks{}))
+  assert.NilError(t, Validate(&MockPersistent{}))
+  assert.NilError(t, Validate(&MockDispatchPointers{}))
+
+  // (bad) Every problem is reported at once
+  err := Validate(&MockValidateTree{})
+  var invalid *ValidationError
+  assert.Assert(t, errors.As(err, &invalid))
+  problems := make([]string, len(invalid.Problems))
+  for i, problem := range invalid.Problems {
+    problems[i] = problem.Error()
+  }
+  assert.DeepEqual(t, problems, []string{
+    "['app'] subcommands ['Deploy'] and ['Ship'] share the name ['ship']",
+    "['app'] flag ['--region']: flag is marked required, required flags may not have default values",
+    "['app'] flags ['Region'] and ['Zone'] share the name ['-r']",
+    "['app'] flag ['--limits'] has unsupported type ['map[string]int']",
+    "['app'] parent field ['Orphan'] of type ['*basicli.MockValidateTree'] doesn't point to an ancestor command",
+    "['app'] method ['Before'] has unsupported signature ['func() error']",
+    "['app deploy'] args ['Src'] and ['Dst'] share position [0]",
+    "['app deploy'] arg ['<rest...>'] collects the remaining args, but isn't a slice",
+    "['app deploy'] method ['CompleteSrc'] has unsupported signature ['func() []string']",
+    "['app deploy'] method ['Exec'] has unsupported signature ['func(string) error']",
+    "['app bad'] method ['Bad'] has unsupported signature ['func(string) error']",
+  })
+  assert.Check(t, errors.Is(err, ErrRequiredAndDefault))
+
+  // (bad) Malformed tags
+  err = Validate(&MockValidateMalformed{})
+  assert.Error(t, err, `found 4 problem(s) in command tree:
+  - ['app'] field ['Port'] has malformed tag ['port,bogus=1']: offset 5: unknown directive ['bogus']
+  - ['app'] field ['Debug'] has malformed tag ['debug,required=yes']: offset 15: invalid boolean ['yes'], expected 'true' or 'false'
+  - ['app'] unexported field ['secret'] is tagged, but ignored
+  - ['app sub'] field ['Host'] has malformed tag ['host,nope=x']: offset 5: unknown directive ['nope']`)
+  var syntax *tag.SyntaxError
+  assert.Assert(t, errors.As(err, &syntax))
+  assert.Equal(t, syntax.Offset, 5)
+
+  // (bad) Malformed tags are errors rather than panics, even without Run
+  err = Unmarshal(&MockValidateMalformed{})
+  assert.Error(t, err, "['app'] field ['Port'] has malformed tag ['port,bogus=1']: offset 5: unknown directive ['bogus']")
+
+  // (bad) Run refuses to run invalid trees
+  assert.Assert(t, errors.As(Run(&MockValidateMalformed{}), &invalid))
+}